@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/coveooss/go-coveo/search"
+	"github.com/coveooss/uabot/scenariolib"
+)
+
+// evaluate Run the evaluate command: measure the relevance of the judged queries of the
+// scenarios file, compare two pipelines, or compare two saved reports.
+func evaluate(args []string) error {
+	flags := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	k := flags.Int("k", scenariolib.DEFAULTEVALUATIONDEPTH, "the number of results evaluated for each query")
+	format := flags.String("format", scenariolib.REPORTFORMATTEXT, "the format of the report: text, json or csv")
+	output := flags.String("output", "", "the file of the report, the standard output when empty")
+	pipeline := flags.String("pipeline", "", "the pipeline evaluated, the pipeline of the scenarios file when empty")
+	comparePipeline := flags.String("compare", "", "a candidate pipeline compared to the evaluated one")
+	baselinePath := flags.String("baseline", "", "a saved JSON report compared to the evaluation")
+	candidatePath := flags.String("candidate", "", "a saved JSON report compared to the baseline report, without searching")
+	flags.Parse(args)
+
+	// Reject the format before truncating the output or searching.
+	switch *format {
+	case scenariolib.REPORTFORMATTEXT, scenariolib.REPORTFORMATJSON, scenariolib.REPORTFORMATCSV:
+	default:
+		return fmt.Errorf("Report format %s is not supported", *format)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	// Compare two saved reports
+	if *candidatePath != "" {
+		if *baselinePath == "" {
+			return errors.New("-candidate needs a -baseline report to compare to")
+		}
+		baseline, err := scenariolib.NewEvaluationReportFromPath(*baselinePath)
+		if err != nil {
+			return err
+		}
+		candidate, err := scenariolib.NewEvaluationReportFromPath(*candidatePath)
+		if err != nil {
+			return err
+		}
+		comparison, err := scenariolib.CompareReports(baseline, candidate)
+		if err != nil {
+			return err
+		}
+		return comparison.Write(out, *format)
+	}
+
+	searchToken := os.Getenv("SEARCHTOKEN")
+	scenarioURL := os.Getenv("SCENARIOSURL")
+	if searchToken == "" || scenarioURL == "" {
+		return errors.New("SEARCHTOKEN and SCENARIOSURL need to be defined as env variables")
+	}
+
+	var conf *scenariolib.Config
+	var err error
+	if os.Getenv("LOCAL") == "true" {
+		conf, err = scenariolib.NewConfigFromPath(scenarioURL)
+	} else {
+		conf, err = scenariolib.NewConfigFromURL(scenarioURL)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := search.NewClient(search.Config{Token: searchToken, UserAgent: conf.RandomData.UserAgents[0], Endpoint: conf.SearchEndpoint})
+	if err != nil {
+		return err
+	}
+	report, err := conf.Evaluate(client, *pipeline, *k)
+	if err != nil {
+		return err
+	}
+
+	var baseline *scenariolib.EvaluationReport
+	switch {
+	case *comparePipeline != "":
+		baseline = report
+		if report, err = conf.Evaluate(client, *comparePipeline, *k); err != nil {
+			return err
+		}
+	case *baselinePath != "":
+		if baseline, err = scenariolib.NewEvaluationReportFromPath(*baselinePath); err != nil {
+			return err
+		}
+	default:
+		return report.Write(out, *format)
+	}
+
+	comparison, err := scenariolib.CompareReports(baseline, report)
+	if err != nil {
+		return err
+	}
+	return comparison.Write(out, *format)
+}
@@ -36,6 +36,17 @@ func main() {
 
 	rand.Seed(seed)
 
+	// The commands write their report on the standard output, the logs go to the standard error.
+	commands := map[string]func([]string) error{"evaluate": evaluate, "monitor": monitor}
+	if command, ok := commands[flag.Arg(0)]; ok {
+		scenariolib.InitLogger(traceOut, os.Stderr, os.Stderr, os.Stderr)
+		if err := command(flag.Args()[1:]); err != nil {
+			scenariolib.Error.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	searchToken := os.Getenv("SEARCHTOKEN")
 	analyticsToken := os.Getenv("UATOKEN")
 	if searchToken == "" || analyticsToken == "" {
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/coveooss/uabot/scenariolib"
+)
+
+// monitor Run the monitor command: observe the rank of the target documents of the
+// SearchAndClick events periodically, save the ranks and print their trends.
+func monitor(args []string) error {
+	flags := flag.NewFlagSet("monitor", flag.ExitOnError)
+	output := flags.String("output", "ranks.csv", "the CSV file where the ranks are appended")
+	depth := flags.Int("depth", scenariolib.DEFAULTMONITORDEPTH, "the number of results searched for the target document")
+	interval := flags.Int("interval", 60, "the time between two observations, in minutes")
+	once := flags.Bool("once", false, "observe the ranks once, then stop")
+	flags.Parse(args)
+
+	searchToken := os.Getenv("SEARCHTOKEN")
+	scenarioURL := os.Getenv("SCENARIOSURL")
+	if searchToken == "" || scenarioURL == "" {
+		return errors.New("SEARCHTOKEN and SCENARIOSURL need to be defined as env variables")
+	}
+	if *interval <= 0 {
+		return errors.New("-interval must be a positive number of minutes")
+	}
+
+	var conf *scenariolib.Config
+	var err error
+	if os.Getenv("LOCAL") == "true" {
+		conf, err = scenariolib.NewConfigFromPath(scenarioURL)
+	} else {
+		conf, err = scenariolib.NewConfigFromURL(scenarioURL)
+	}
+	if err != nil {
+		return err
+	}
+	targets, err := conf.MonitorTargets()
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return errors.New("No SearchAndClick event to monitor in the scenarios")
+	}
+
+	for {
+		observations, err := scenariolib.ObserveRanks(targets, searchToken, *depth)
+		if err != nil {
+			return err
+		}
+		if err = scenariolib.AppendRankObservations(*output, observations); err != nil {
+			return err
+		}
+
+		file, err := os.Open(*output)
+		if err != nil {
+			return err
+		}
+		history, err := scenariolib.ReadRankObservations(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if err = scenariolib.WriteRankTrends(os.Stdout, scenariolib.RankTrends(history)); err != nil {
+			return err
+		}
+
+		if *once {
+			return nil
+		}
+		time.Sleep(time.Duration(*interval) * time.Minute)
+	}
+}
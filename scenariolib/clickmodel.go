@@ -0,0 +1,126 @@
+package scenariolib
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	// CLICKMODELCASCADE The user examines the results top-down and clicks at most once.
+	CLICKMODELCASCADE string = "cascade"
+	// CLICKMODELPOSITION The user examines each rank with its own probability.
+	CLICKMODELPOSITION string = "position"
+	// CLICKMODELDEPENDENT The user examines the results top-down and may continue after each click.
+	CLICKMODELDEPENDENT string = "dependent"
+	// DEFAULTATTRACTIVENESS The probability to click on an examined result when not specified.
+	DEFAULTATTRACTIVENESS float64 = 0.3
+	// DEFAULTCONTINUATION The probability to continue examining the results after a click when not specified.
+	DEFAULTCONTINUATION float64 = 0.3
+)
+
+// ClickModel How the user examines and clicks the results of a query. The values per rank
+// start at the first result of the page, the last value is used for the following ranks.
+// Attractiveness The probability to click on an examined result, by rank
+// Examination    The probability to examine a result in the position model, 1/(rank+1) when not specified
+// Continuation   The probability to examine the next results after a click in the dependent model, by rank
+// MaxClicks      The maximum number of clicks, 1 by default in the position model
+type ClickModel struct {
+	Type           string    `json:"type"`
+	Attractiveness []float64 `json:"attractiveness,omitempty"`
+	Examination    []float64 `json:"examination,omitempty"`
+	Continuation   []float64 `json:"continuation,omitempty"`
+	MaxClicks      int       `json:"maxClicks,omitempty"`
+}
+
+// IsValid Validate the type and the probabilities of the click model.
+func (model *ClickModel) IsValid() (bool, string) {
+	switch model.Type {
+	case CLICKMODELCASCADE, CLICKMODELPOSITION, CLICKMODELDEPENDENT:
+	default:
+		return false, fmt.Sprintf("Click model %s is not supported.", model.Type)
+	}
+	for _, probabilities := range [][]float64{model.Attractiveness, model.Examination, model.Continuation} {
+		for _, probability := range probabilities {
+			if probability < 0 || probability > 1 {
+				return false, "The probabilities of a click model must be between 0 and 1."
+			}
+		}
+	}
+	if model.MaxClicks < 0 {
+		return false, "maxClicks must be a positive integer."
+	}
+	return true, ""
+}
+
+// perRank Returns the value of the rank, the last value for the ranks after the values.
+func perRank(values []float64, rank int, defaultValue float64) float64 {
+	if len(values) == 0 {
+		return defaultValue
+	}
+	if rank < len(values) {
+		return values[rank]
+	}
+	return values[len(values)-1]
+}
+
+func (model *ClickModel) attractiveness(rank int) float64 {
+	return perRank(model.Attractiveness, rank, DEFAULTATTRACTIVENESS)
+}
+
+func (model *ClickModel) continuation(rank int) float64 {
+	return perRank(model.Continuation, rank, DEFAULTCONTINUATION)
+}
+
+// ExaminationProbability Returns the probability that the user examines the result at the rank.
+func (model *ClickModel) ExaminationProbability(rank int) float64 {
+	if model.Type == CLICKMODELPOSITION {
+		return perRank(model.Examination, rank, 1/float64(rank+1))
+	}
+	probability := 1.0
+	for i := 0; i < rank; i++ {
+		if model.Type == CLICKMODELDEPENDENT {
+			// The user goes past a result when not clicking it, or when continuing after the click.
+			probability *= 1 - model.attractiveness(i) + model.attractiveness(i)*model.continuation(i)
+		} else {
+			probability *= 1 - model.attractiveness(i)
+		}
+	}
+	return probability
+}
+
+// ClickRanks Simulate the user on a page of results and returns the ranks clicked, in order.
+func (model *ClickModel) ClickRanks(numberOfResults int) []int {
+	maxClicks := model.MaxClicks
+	switch {
+	case model.Type == CLICKMODELCASCADE:
+		maxClicks = 1
+	case model.Type == CLICKMODELPOSITION && maxClicks == 0:
+		maxClicks = 1
+	}
+
+	ranks := []int{}
+	for rank := 0; rank < numberOfResults; rank++ {
+		if maxClicks > 0 && len(ranks) >= maxClicks {
+			break
+		}
+		if model.Type == CLICKMODELPOSITION && rand.Float64() >= model.ExaminationProbability(rank) {
+			continue
+		}
+		if rand.Float64() >= model.attractiveness(rank) {
+			continue
+		}
+		ranks = append(ranks, rank)
+		if model.Type == CLICKMODELDEPENDENT && rand.Float64() >= model.continuation(rank) {
+			break
+		}
+	}
+	return ranks
+}
+
+// clickModel Returns the click model of the event, or the one of the config.
+func (v *Visit) clickModel(eventModel *ClickModel) *ClickModel {
+	if eventModel != nil {
+		return eventModel
+	}
+	return v.Config.ClickModel
+}
@@ -0,0 +1,107 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestClickModelInvalid(t *testing.T) {
+	var testModels = []scenariolib.ClickModel{
+		{},
+		{Type: "random"},
+		{Type: "cascade", Attractiveness: []float64{0.5, 1.5}},
+		{Type: "position", Examination: []float64{-0.1}},
+		{Type: "dependent", MaxClicks: -1},
+	}
+
+	for _, model := range testModels {
+		valid, _ := model.IsValid()
+		assert(t, !valid, "Expected click model %v to be invalid.", model)
+	}
+}
+
+func TestClickModelExaminationProbability(t *testing.T) {
+	cascade := &scenariolib.ClickModel{Type: "cascade", Attractiveness: []float64{0.5}}
+	equals(t, 1.0, cascade.ExaminationProbability(0))
+	equals(t, 0.25, cascade.ExaminationProbability(2))
+
+	dependent := &scenariolib.ClickModel{Type: "dependent", Attractiveness: []float64{0.5}, Continuation: []float64{0.5}}
+	equals(t, 0.75, dependent.ExaminationProbability(1))
+
+	position := &scenariolib.ClickModel{Type: "position"}
+	equals(t, 0.25, position.ExaminationProbability(3))
+	position.Examination = []float64{1, 0.6, 0.4}
+	assert(t, math.Abs(position.ExaminationProbability(7)-0.4) < 1e-9, "Expected the last examination probability after the values.")
+}
+
+func TestClickModelClickRanks(t *testing.T) {
+	cascade := &scenariolib.ClickModel{Type: "cascade", Attractiveness: []float64{0, 0, 1}}
+	equals(t, []int{2}, cascade.ClickRanks(5))
+	equals(t, []int{}, cascade.ClickRanks(2))
+
+	dependent := &scenariolib.ClickModel{Type: "dependent", Attractiveness: []float64{1}, Continuation: []float64{1}}
+	equals(t, []int{0, 1, 2}, dependent.ClickRanks(3))
+	dependent.MaxClicks = 2
+	equals(t, []int{0, 1}, dependent.ClickRanks(3))
+	dependent.Continuation = []float64{0}
+	equals(t, []int{0}, dependent.ClickRanks(3))
+
+	position := &scenariolib.ClickModel{Type: "position", Examination: []float64{0, 1}, Attractiveness: []float64{1}, MaxClicks: 5}
+	equals(t, []int{1, 2, 3}, position.ClickRanks(4))
+}
+
+func TestClickEventClickModel(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// Keep the position of every click sent to the analytics.
+	positions := []float64{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == defaults.ANALYTICS_REST_PATH+"click/" {
+			body := map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&body)
+			positions = append(positions, body["documentPosition"].(float64))
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.ClickModel = &scenariolib.ClickModel{Type: "dependent", Attractiveness: []float64{1, 0, 1}, Continuation: []float64{1}}
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.WaitBetweenActions = false
+	v.LastResponse = &search.Response{TotalCount: 3, Results: []search.Result{
+		{URI: "uri1", Raw: map[string]interface{}{"urihash": "hash1"}},
+		{URI: "uri2", Raw: map[string]interface{}{"urihash": "hash2"}},
+		{URI: "uri3", Raw: map[string]interface{}{"urihash": "hash3"}},
+	}}
+
+	// The click model of the config clicks on the first and third results.
+	ok(t, (&scenariolib.ClickEvent{ClickRank: -1, Probability: 1}).Execute(v))
+	equals(t, []float64{1, 3}, positions)
+
+	// The click model of the event has precedence, the third result is never examined.
+	positions = positions[:0]
+	event := &scenariolib.ClickEvent{ClickRank: 2, Probability: 1, ClickModel: &scenariolib.ClickModel{Type: "cascade", Attractiveness: []float64{1}}}
+	ok(t, event.Execute(v))
+	equals(t, 0, len(positions))
+
+	// The click model of the config only applies to the clicks without a rank.
+	conf.ClickModel = &scenariolib.ClickModel{Type: "cascade", Attractiveness: []float64{1}}
+	ok(t, (&scenariolib.ClickEvent{ClickRank: 2, Probability: 1}).Execute(v))
+	equals(t, []float64{3}, positions)
+}
@@ -0,0 +1,167 @@
+package scenariolib
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// FALLBACKFAIL Fail the visit when no target is found, the default.
+	FALLBACKFAIL string = "fail"
+	// FALLBACKRANK Click on the result at the fallbackRank when no target is found.
+	FALLBACKRANK string = "rank"
+	// FALLBACKRANDOM Click on a random result when no target is found.
+	FALLBACKRANDOM string = "random"
+	// FALLBACKABANDON Do not click when no target is found.
+	FALLBACKABANDON string = "abandon"
+)
+
+// ClickTarget A document to click on, a result matches when it matches all the conditions.
+// Title       Contained in the title of the result, without case
+// URI         The exact URI of the result
+// URIHash     The urihash of the result
+// PermanentID The permanentid of the result
+// Fields      Regex patterns the values of the fields of the result must match, by field
+// Weight      The weight of the target in a pool of targets, 1 when not specified
+type ClickTarget struct {
+	Title        string            `json:"title,omitempty"`
+	URI          string            `json:"uri,omitempty"`
+	URIHash      string            `json:"urihash,omitempty"`
+	PermanentID  string            `json:"permanentid,omitempty"`
+	Fields       map[string]string `json:"fields,omitempty"`
+	Weight       float64           `json:"weight,omitempty"`
+	fieldRegexes map[string]*regexp.Regexp
+}
+
+// IsValid Validate the target has a condition, and compile its patterns.
+func (target *ClickTarget) IsValid() (bool, string) {
+	if target.Title == "" && target.URI == "" && target.URIHash == "" && target.PermanentID == "" && len(target.Fields) == 0 {
+		return false, "A click target needs a title, uri, urihash, permanentid or fields."
+	}
+	if target.Weight < 0 {
+		return false, "The weight of a click target must be positive."
+	}
+	target.fieldRegexes = make(map[string]*regexp.Regexp)
+	for field, pattern := range target.Fields {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, "Failed to compile regex pattern : " + err.Error()
+		}
+		target.fieldRegexes[strings.TrimPrefix(field, "@")] = regex
+	}
+	return true, ""
+}
+
+// Matches Whether the result matches all the conditions of the target.
+func (target *ClickTarget) Matches(result search.Result) bool {
+	if target.Title != "" && !strings.Contains(strings.ToLower(result.Title), strings.ToLower(target.Title)) {
+		return false
+	}
+	if target.URI != "" && result.URI != target.URI {
+		return false
+	}
+	if target.URIHash != "" && getFieldValueFromRaw(result.Raw, "urihash") != target.URIHash {
+		return false
+	}
+	if target.PermanentID != "" && getFieldValueFromRaw(result.Raw, "permanentid") != target.PermanentID {
+		return false
+	}
+	for field, regex := range target.fieldRegexes {
+		if !matchesFieldValue(result.Raw[field], regex) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesFieldValue Whether a field value, or one of the values of a multi value field, matches.
+func matchesFieldValue(value interface{}, regex *regexp.Regexp) bool {
+	switch value := value.(type) {
+	case string:
+		return regex.MatchString(value)
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok && regex.MatchString(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String Describes the conditions of the target.
+func (target *ClickTarget) String() string {
+	conditions := []string{}
+	if target.Title != "" {
+		conditions = append(conditions, "title~"+target.Title)
+	}
+	if target.URI != "" {
+		conditions = append(conditions, "uri="+target.URI)
+	}
+	if target.URIHash != "" {
+		conditions = append(conditions, "urihash="+target.URIHash)
+	}
+	if target.PermanentID != "" {
+		conditions = append(conditions, "permanentid="+target.PermanentID)
+	}
+	fields := make([]string, 0, len(target.Fields))
+	for field := range target.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		conditions = append(conditions, fmt.Sprintf("%s=~%s", field, target.Fields[field]))
+	}
+	return strings.Join(conditions, " ")
+}
+
+func (target *ClickTarget) weight() float64 {
+	if target.Weight == 0 {
+		return 1
+	}
+	return target.Weight
+}
+
+// FindDocumentRankByTarget Looks through the last response to a query to find the rank of
+// the first document matching the target
+func (v *Visit) FindDocumentRankByTarget(target *ClickTarget) int {
+	if v.LastResponse == nil {
+		return -1
+	}
+	for i := 0; i < len(v.LastResponse.Results); i++ {
+		if target.Matches(v.LastResponse.Results[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// shuffleTargets Returns the targets in a random order, the targets with a higher weight
+// coming first more often.
+func shuffleTargets(targets []*ClickTarget) []*ClickTarget {
+	remaining := append([]*ClickTarget{}, targets...)
+	shuffled := []*ClickTarget{}
+	for len(remaining) > 0 {
+		total := 0.0
+		for _, target := range remaining {
+			total += target.weight()
+		}
+		roll := rand.Float64() * total
+		picked := len(remaining) - 1
+		for i, target := range remaining {
+			roll -= target.weight()
+			if roll < 0 {
+				picked = i
+				break
+			}
+		}
+		shuffled = append(shuffled, remaining[picked])
+		remaining = append(remaining[:picked], remaining[picked+1:]...)
+	}
+	return shuffled
+}
@@ -0,0 +1,130 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestClickTargetMatches(t *testing.T) {
+	result := search.Result{URI: "http://rocky", Title: "Rocky Balboa", Raw: map[string]interface{}{
+		"sysurihash":  "hash1",
+		"permanentid": "id1",
+		"genres":      []interface{}{"Drama", "Sport"},
+	}}
+
+	var testTargets = []struct {
+		target  scenariolib.ClickTarget
+		matches bool
+	}{
+		{scenariolib.ClickTarget{URI: "http://rocky"}, true},
+		{scenariolib.ClickTarget{URI: "http://rocky/2"}, false},
+		{scenariolib.ClickTarget{URIHash: "hash1", PermanentID: "id1"}, true},
+		{scenariolib.ClickTarget{URIHash: "hash1", PermanentID: "id2"}, false},
+		{scenariolib.ClickTarget{Title: "balboa", Fields: map[string]string{"@genres": "^Sport$"}}, true},
+		{scenariolib.ClickTarget{Fields: map[string]string{"genres": "Comedy"}}, false},
+	}
+
+	for _, test := range testTargets {
+		valid, message := test.target.IsValid()
+		assert(t, valid, "Expected target to be valid, was false with error: %s", message)
+		equals(t, test.matches, test.target.Matches(result))
+	}
+
+	valid, _ := (&scenariolib.ClickTarget{Weight: 2}).IsValid()
+	assert(t, !valid, "Expected a target without conditions to be invalid.")
+	valid, _ = (&scenariolib.ClickTarget{Fields: map[string]string{"genres": "("}}).IsValid()
+	assert(t, !valid, "Expected a target with an invalid pattern to be invalid.")
+}
+
+func TestSearchAndClickEventTargetsValid(t *testing.T) {
+	var testEvents = []string{
+		`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash1"}, "docClickTitle": "rocky"}`,
+		`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash1"}, "targets": [{"urihash": "hash2"}]}`,
+		`{"queryText": "rocky", "probability": 1, "targets": [{"weight": 1}]}`,
+		`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash1"}, "fallback": "retry"}`,
+		`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash1"}, "fallback": "rank", "fallbackRank": -1}`,
+	}
+
+	for _, test := range testEvents {
+		event := &scenariolib.SearchAndClickEvent{}
+		ok(t, json.Unmarshal([]byte(test), event))
+		valid, _ := event.IsValid()
+		assert(t, !valid, "Expected event %s to be invalid.", test)
+	}
+}
+
+func TestSearchAndClickEventTargets(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// Keep the position of every click sent to the analytics.
+	positions := []float64{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case defaults.SEARCH_REST_PATH:
+			query := &search.Query{}
+			json.NewDecoder(req.Body).Decode(query)
+			if query.Q == "nothing" {
+				rw.Write([]byte(`{"totalCount": 0, "results": []}`))
+				return
+			}
+			rw.Write([]byte(`{"totalCount": 3, "results": [
+				{"uri": "uri1", "raw": {"urihash": "hash1", "permanentid": "id1"}},
+				{"uri": "uri2", "raw": {"urihash": "hash2", "permanentid": "id2"}},
+				{"uri": "uri3", "raw": {"urihash": "hash3", "permanentid": "id3"}}
+			]}`))
+			return
+		case defaults.ANALYTICS_REST_PATH + "click/":
+			body := map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&body)
+			positions = append(positions, body["documentPosition"].(float64))
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.TimeBetweenActions = 1
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	execute := func(arguments string) error {
+		event := &scenariolib.SearchAndClickEvent{}
+		ok(t, json.Unmarshal([]byte(arguments), event))
+		valid, message := event.IsValid()
+		assert(t, valid, "Expected event to be valid, was false with error: %s", message)
+		return event.Execute(v)
+	}
+
+	// The target of the pool absent from the results is skipped.
+	ok(t, execute(`{"queryText": "rocky", "probability": 1, "targets": [{"uri": "uri9", "weight": 100}, {"permanentid": "id3", "weight": 0.1}]}`))
+	equals(t, []float64{3}, positions)
+
+	// Without a fallback, a missing target fails the event.
+	notok(t, execute(`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash9"}}`))
+
+	// The fallbacks click on a rank, or abandon the search.
+	positions = positions[:0]
+	ok(t, execute(`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash9"}, "fallback": "rank", "fallbackRank": 1}`))
+	ok(t, execute(`{"queryText": "rocky", "probability": 1, "target": {"urihash": "hash9"}, "fallback": "abandon"}`))
+	equals(t, []float64{2}, positions)
+
+	// Without results, the fallbacks abandon the search.
+	positions = positions[:0]
+	ok(t, execute(`{"queryText": "nothing", "probability": 1, "target": {"urihash": "hash1"}, "fallback": "rank", "fallbackRank": 1}`))
+	ok(t, execute(`{"queryText": "nothing", "probability": 1, "target": {"urihash": "hash1"}, "fallback": "random"}`))
+	notok(t, execute(`{"queryText": "nothing", "probability": 1, "target": {"urihash": "hash1"}}`))
+	equals(t, 0, len(positions))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/coveo/uabot/defaults"
 )
@@ -57,6 +58,16 @@ type Config struct {
 	// Pipeline The pipeline for the search queries.
 	Pipeline string `json:"pipeline,omitempty"`
 
+	// SearchHub The search hub of the queries, also the default OriginLevel1.
+	SearchHub string `json:"searchHub,omitempty"`
+
+	// Tab The tab of the queries, also the default OriginLevel2.
+	Tab string `json:"tab,omitempty"`
+
+	// Context The context of the queries, used by Coveo ML for personalization. A list of values
+	// is randomized once per visit.
+	Context map[string]interface{} `json:"context,omitempty"`
+
 	// DontWaitBetweenVisits Do not wait between the visits.
 	DontWaitBetweenVisits bool `json:"dontWaitBetweenVisits"`
 
@@ -92,6 +103,42 @@ type Config struct {
 
 	// DefaultPageViewField Override of the DefaultPageViewField for ALL pageView Events.
 	DefaultPageViewField string `json:"defaultPageViewField,omitempty"`
+
+	// QueryDefaults The parameters of the queries (number of results, sort, groupBy, etc.).
+	QueryDefaults QueryDefaults `json:"queryDefaults,omitempty"`
+
+	// EventBlocks Named blocks of events that scenarios can reuse with an Include event.
+	EventBlocks map[string][]JSONEvent `json:"eventBlocks,omitempty"`
+
+	// Typos The rates of the typos in the misspelled variants of the good queries.
+	Typos TypoRates `json:"typos,omitempty"`
+
+	// QueryPopularity How often each query of the pools is picked.
+	QueryPopularity QueryPopularity `json:"queryPopularity,omitempty"`
+
+	// QueryTemplates Templates of queries with slots, used by the searches with templateQuery.
+	QueryTemplates QueryTemplates `json:"queryTemplates,omitempty"`
+
+	// ClickModel How the users examine and click the results, for the clicks without a rank.
+	ClickModel *ClickModel `json:"clickModel,omitempty"`
+
+	// Dwell How long the users stay on the documents they click, and the engagement events sent after.
+	Dwell *Dwell `json:"dwell,omitempty"`
+
+	// JudgmentsFile The path or URL of a JSON file with the judgments, replacing Judgments.
+	JudgmentsFile string `json:"judgmentsFile,omitempty"`
+
+	// Judgments The graded relevance of documents for queries, used by the JudgedSearch events.
+	Judgments *Judgments `json:"judgments,omitempty"`
+
+	// Harvest Settings of the queries harvested from the index.
+	Harvest Harvest `json:"harvest,omitempty"`
+
+	// HarvestedGoodQueries The good queries harvested from the index, added to the GoodQueries.
+	HarvestedGoodQueries []string `json:"-"`
+
+	// HarvestedBadQueries The bad queries harvested from the index, added to the BadQueries.
+	HarvestedBadQueries []string `json:"-"`
 }
 
 // RandomData An override of the bot default random/fake data.
@@ -120,9 +167,10 @@ type RandomData struct {
 }
 
 // RandomCustomData Structure of random values for a specific API name.
+// Values can be static values or customData generators.
 type RandomCustomData struct {
-	APIName string   `json:"apiname"`
-	Values  []string `json:"values"`
+	APIName string        `json:"apiname"`
+	Values  []interface{} `json:"values"`
 }
 
 // NewConfigFromPath Create a new config from a JSON config file path
@@ -142,6 +190,10 @@ func NewConfigFromPath(jsonPath string) (*Config, error) {
 
 	fillDefaults(c)
 
+	if err = c.validate(); err != nil {
+		return nil, err
+	}
+
 	err = c.makeScenarioMap()
 	if err != nil {
 		return nil, fmt.Errorf("Error making scenario map : %v", err)
@@ -168,6 +220,10 @@ func NewConfigFromURL(jsonURL string) (*Config, error) {
 
 	fillDefaults(c)
 
+	if err = c.validate(); err != nil {
+		return nil, err
+	}
+
 	err = c.makeScenarioMap()
 	if err != nil {
 		return nil, errors.New("Cannot make the scenario map")
@@ -175,12 +231,45 @@ func NewConfigFromURL(jsonURL string) (*Config, error) {
 	return c, nil
 }
 
+// validate Validate the settings of the config, load its judgments and resolve its scenarios.
+func (c *Config) validate() error {
+	if valid, message := c.QueryPopularity.IsValid(); !valid {
+		return errors.New(message)
+	}
+	if valid, message := c.QueryTemplates.IsValid(); !valid {
+		return errors.New(message)
+	}
+	if valid, message := c.Harvest.IsValid(); !valid {
+		return errors.New(message)
+	}
+	if c.ClickModel != nil {
+		if valid, message := c.ClickModel.IsValid(); !valid {
+			return errors.New(message)
+		}
+	}
+	if c.Dwell != nil {
+		if valid, message := c.Dwell.IsValid(); !valid {
+			return errors.New(message)
+		}
+	}
+	if err := c.loadJudgments(); err != nil {
+		return err
+	}
+	if err := c.resolveScenarios(); err != nil {
+		return fmt.Errorf("Error resolving scenarios : %v", err)
+	}
+	return nil
+}
+
 // makeScenarioMap Private function to create the map of scenarios
 // from the config that was built from a json file
 func (c *Config) makeScenarioMap() error {
 	scenarioMap := []*Scenario{}
 	totalWeight := 0
 	for i := 0; i < len(c.Scenarios); i++ {
+		if valid, message := c.Scenarios[i].IsValid(); !valid {
+			return fmt.Errorf("Scenario %s is invalid : %s", c.Scenarios[i].Name, message)
+		}
 		weight := c.Scenarios[i].Weight
 		totalWeight += weight
 		for j := 0; j < weight; j++ {
@@ -191,6 +280,195 @@ func (c *Config) makeScenarioMap() error {
 	return nil
 }
 
+// resolveScenarios Private function to expand the Include events and the scenarios
+// extending other scenarios, detecting cycles in both.
+func (c *Config) resolveScenarios() error {
+	expandedBlocks := make(map[string][]JSONEvent)
+	for name := range c.EventBlocks {
+		if _, err := c.expandBlock(name, expandedBlocks, []string{}); err != nil {
+			return err
+		}
+	}
+	c.EventBlocks = expandedBlocks
+
+	scenariosByName := make(map[string]*Scenario)
+	for _, scenario := range c.Scenarios {
+		scenariosByName[scenario.Name] = scenario
+	}
+	resolved := make(map[*Scenario]bool)
+	for _, scenario := range c.Scenarios {
+		if err := resolveExtends(scenario, scenariosByName, resolved, []string{}); err != nil {
+			return err
+		}
+	}
+
+	for _, scenario := range c.Scenarios {
+		events, err := c.expandIncludes(scenario.Events, expandedBlocks, []string{})
+		if err != nil {
+			return fmt.Errorf("Scenario %s : %v", scenario.Name, err)
+		}
+		scenario.Events = events
+		for name, state := range scenario.States {
+			if state == nil || state.Event == nil || state.Event.Type != INCLUDEEVENTTYPE {
+				continue
+			}
+			include, err := parseInclude(state.Event)
+			if err != nil {
+				return fmt.Errorf("Scenario %s state %s : %v", scenario.Name, name, err)
+			}
+			if _, ok := c.EventBlocks[include.Block]; !ok {
+				return fmt.Errorf("Scenario %s state %s : event block %s is not defined", scenario.Name, name, include.Block)
+			}
+		}
+	}
+	return nil
+}
+
+// expandBlock Returns the events of a block with its Include events replaced, path is the
+// chain of blocks being expanded, used to detect cycles.
+func (c *Config) expandBlock(name string, expandedBlocks map[string][]JSONEvent, path []string) ([]JSONEvent, error) {
+	if events, ok := expandedBlocks[name]; ok {
+		return events, nil
+	}
+	for _, parent := range path {
+		if parent == name {
+			return nil, fmt.Errorf("Event blocks include each other : %s", strings.Join(append(path, name), " -> "))
+		}
+	}
+	events, ok := c.EventBlocks[name]
+	if !ok {
+		return nil, fmt.Errorf("Event block %s is not defined", name)
+	}
+	events, err := c.expandIncludes(events, expandedBlocks, append(path, name))
+	if err != nil {
+		return nil, err
+	}
+	expandedBlocks[name] = events
+	return events, nil
+}
+
+// expandIncludes Returns the events with the Include events replaced by the events of their block.
+func (c *Config) expandIncludes(events []JSONEvent, expandedBlocks map[string][]JSONEvent, path []string) ([]JSONEvent, error) {
+	expanded := []JSONEvent{}
+	for i := range events {
+		if events[i].Type != INCLUDEEVENTTYPE {
+			expanded = append(expanded, events[i])
+			continue
+		}
+		include, err := parseInclude(&events[i])
+		if err != nil {
+			return nil, err
+		}
+		blockEvents, err := c.expandBlock(include.Block, expandedBlocks, path)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, blockEvents...)
+	}
+	return expanded, nil
+}
+
+// parseInclude Returns the validated arguments of an Include event.
+func parseInclude(e *JSONEvent) (*IncludeEvent, error) {
+	include := &IncludeEvent{}
+	if err := json.Unmarshal(e.Arguments, include); err != nil {
+		return nil, err
+	}
+	if valid, message := include.IsValid(); !valid {
+		return nil, errors.New(message)
+	}
+	return include, nil
+}
+
+// resolveExtends Copy what a scenario inherits from the scenario it extends, resolving the
+// base scenario first. path is the chain of scenarios being resolved, used to detect cycles.
+func resolveExtends(scenario *Scenario, scenariosByName map[string]*Scenario, resolved map[*Scenario]bool, path []string) error {
+	if resolved[scenario] || scenario.Extends == "" {
+		return nil
+	}
+	for _, child := range path {
+		if child == scenario.Name {
+			return fmt.Errorf("Scenarios extend each other : %s", strings.Join(append(path, scenario.Name), " -> "))
+		}
+	}
+	base, ok := scenariosByName[scenario.Extends]
+	if !ok {
+		return fmt.Errorf("Scenario %s extends %s which is not defined", scenario.Name, scenario.Extends)
+	}
+	if err := resolveExtends(base, scenariosByName, resolved, append(path, scenario.Name)); err != nil {
+		return err
+	}
+	scenario.inherit(base)
+	resolved[scenario] = true
+	return nil
+}
+
+// ForScenario Returns the config of a visit executing the scenario: a copy of the config
+// with the session settings overridden by the scenario.
+func (c *Config) ForScenario(scenario *Scenario) *Config {
+	merged := *c
+	if scenario.Pipeline != "" {
+		merged.Pipeline = scenario.Pipeline
+	}
+	if scenario.GlobalFilter != "" {
+		merged.GlobalFilter = scenario.GlobalFilter
+	}
+	if scenario.SearchHub != "" {
+		merged.SearchHub = scenario.SearchHub
+		merged.DefaultOriginLevel1 = scenario.SearchHub
+	}
+	if scenario.Tab != "" {
+		merged.Tab = scenario.Tab
+	}
+	merged.Context = mergeContext(c.Context, scenario.Context)
+	merged.QueryDefaults = c.QueryDefaults.merge(scenario.QueryDefaults)
+	if scenario.SearchEndpoint != "" {
+		merged.SearchEndpoint = scenario.SearchEndpoint
+	}
+	if scenario.AnalyticsEndpoint != "" {
+		merged.AnalyticsEndpoint = scenario.AnalyticsEndpoint
+	}
+	if scenario.DefaultOriginLevel1 != "" {
+		merged.DefaultOriginLevel1 = scenario.DefaultOriginLevel1
+	}
+	if scenario.DefaultOriginLevel2 != "" {
+		merged.DefaultOriginLevel2 = scenario.DefaultOriginLevel2
+	}
+	if scenario.DefaultOriginLevel3 != "" {
+		merged.DefaultOriginLevel3 = scenario.DefaultOriginLevel3
+	}
+	if scenario.TimeBetweenActions > 0 {
+		merged.TimeBetweenActions = scenario.TimeBetweenActions
+	}
+	if scenario.AnonymousThreshold != nil {
+		merged.AnonymousThreshold = *scenario.AnonymousThreshold
+	}
+	merged.RandomCustomData = mergeRandomCustomData(c.RandomCustomData, scenario.RandomCustomData)
+	return &merged
+}
+
+// mergeRandomCustomData Returns the random custom data of base with the overrides added,
+// an override replaces the base random custom data with the same apiname.
+func mergeRandomCustomData(base []*RandomCustomData, overrides []*RandomCustomData) []*RandomCustomData {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := []*RandomCustomData{}
+	for _, elem := range base {
+		overridden := false
+		for _, override := range overrides {
+			if override.APIName == elem.APIName {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, elem)
+		}
+	}
+	return append(merged, overrides...)
+}
+
 // Fill all the default values that have not been overwritten: Endpoints, origin, etc.
 func fillDefaults(c *Config) {
 	fillRandomData(c)
@@ -208,7 +486,11 @@ func fillDefaults(c *Config) {
 	}
 
 	if c.DefaultOriginLevel1 == "" {
-		c.DefaultOriginLevel1 = defaults.DEFAULTORIGIN1
+		if c.SearchHub != "" {
+			c.DefaultOriginLevel1 = c.SearchHub
+		} else {
+			c.DefaultOriginLevel1 = defaults.DEFAULTORIGIN1
+		}
 	}
 
 }
@@ -0,0 +1,126 @@
+package scenariolib_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/scenariolib"
+)
+
+// writeTestConfig writes a JSON config in a temporary file and returns its path.
+func writeTestConfig(t testing.TB, config string) string {
+	file, err := ioutil.TempFile("", "uabot-config")
+	ok(t, err)
+	_, err = file.WriteString(config)
+	ok(t, err)
+	ok(t, file.Close())
+	return file.Name()
+}
+
+func TestConfigEventBlocksAndExtends(t *testing.T) {
+	path := writeTestConfig(t, `{
+		"eventBlocks": {
+			"opening": [
+				{"type": "SetOrigin", "arguments": {"originLevel1": "Community"}},
+				{"type": "Include", "arguments": {"block": "tab"}}
+			],
+			"tab": [
+				{"type": "TabChange", "arguments": {"name": "All"}}
+			]
+		},
+		"scenarios": [{
+			"name": "base",
+			"weight": 0,
+			"language": "fr",
+			"mobile": true,
+			"defaultOriginLevel1": "Hub",
+			"customData": {"base": "one", "shared": "base"},
+			"events": [
+				{"type": "Include", "arguments": {"block": "opening"}}
+			]
+		}, {
+			"name": "child",
+			"weight": 1,
+			"extends": "base",
+			"language": "en",
+			"customData": {"shared": "child"},
+			"events": [
+				{"type": "Search", "arguments": {"queryText": "test"}}
+			]
+		}]
+	}`)
+	defer os.Remove(path)
+
+	conf, err := scenariolib.NewConfigFromPath(path)
+	ok(t, err)
+
+	equals(t, 1, len(conf.ScenarioMap))
+	child := conf.ScenarioMap[0]
+
+	equals(t, "en", child.Language)
+	assert(t, child.Mobile, "Expected child to inherit mobile from base.")
+	equals(t, "Hub", child.DefaultOriginLevel1)
+	equals(t, map[string]interface{}{"base": "one", "shared": "child"}, child.CustomData)
+
+	types := []string{}
+	for _, event := range child.Events {
+		types = append(types, event.Type)
+	}
+	equals(t, []string{"SetOrigin", "TabChange", "Search"}, types)
+}
+
+func TestConfigCycles(t *testing.T) {
+	var testConfigs = map[string]string{
+		"blocks cycle":      `{"eventBlocks": {"a": [{"type": "Include", "arguments": {"block": "b"}}], "b": [{"type": "Include", "arguments": {"block": "a"}}]}}`,
+		"undefined block":   `{"scenarios": [{"name": "s", "weight": 1, "events": [{"type": "Include", "arguments": {"block": "nope"}}]}]}`,
+		"extends cycle":     `{"scenarios": [{"name": "a", "extends": "b", "weight": 1}, {"name": "b", "extends": "a", "weight": 1}]}`,
+		"undefined extends": `{"scenarios": [{"name": "a", "extends": "nope", "weight": 1}]}`,
+	}
+
+	for name, config := range testConfigs {
+		path := writeTestConfig(t, config)
+		_, err := scenariolib.NewConfigFromPath(path)
+		os.Remove(path)
+		assert(t, err != nil, "Expected config with %s to be invalid.", name)
+	}
+}
+
+func TestConfigForScenario(t *testing.T) {
+	path := writeTestConfig(t, `{
+		"pipeline": "global",
+		"globalfilter": "@source==Community",
+		"defaultOriginLevel1": "Community",
+		"anonymousThreshold": 0.5,
+		"timeBetweenActions": 3,
+		"randomCustomData": [{"apiname": "kept", "values": ["global"]}, {"apiname": "replaced", "values": ["global"]}],
+		"scenarios": [{
+			"name": "agent",
+			"weight": 1,
+			"pipeline": "agent",
+			"defaultOriginLevel1": "AgentConsole",
+			"anonymousThreshold": 0,
+			"randomCustomData": [{"apiname": "replaced", "values": ["agent"]}],
+			"events": []
+		}]
+	}`)
+	defer os.Remove(path)
+
+	conf, err := scenariolib.NewConfigFromPath(path)
+	ok(t, err)
+
+	visitConf := conf.ForScenario(conf.Scenarios[0])
+
+	equals(t, "agent", visitConf.Pipeline)
+	equals(t, "@source==Community", visitConf.GlobalFilter)
+	equals(t, "AgentConsole", visitConf.DefaultOriginLevel1)
+	equals(t, 0.0, visitConf.AnonymousThreshold)
+	equals(t, 3, visitConf.TimeBetweenActions)
+	equals(t, 2, len(visitConf.RandomCustomData))
+	equals(t, "kept", visitConf.RandomCustomData[0].APIName)
+	equals(t, []interface{}{"agent"}, visitConf.RandomCustomData[1].Values)
+
+	// The global config is not modified.
+	equals(t, "global", conf.Pipeline)
+	equals(t, 0.5, conf.AnonymousThreshold)
+}
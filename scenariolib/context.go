@@ -0,0 +1,58 @@
+package scenariolib
+
+import (
+	"math/rand"
+)
+
+// CONTEXTCUSTOMDATAPREFIX The prefix of the customData keys reflecting the query context in the analytics events.
+const CONTEXTCUSTOMDATAPREFIX string = "context_"
+
+// mergeContext Returns the context values of base with the overrides added.
+func mergeContext(base map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]interface{})
+	for k, value := range base {
+		merged[k] = value
+	}
+	for k, value := range overrides {
+		merged[k] = value
+	}
+	return merged
+}
+
+// evaluateContextValue Returns the value of a context entry: a random pick if the value is a
+// list, a generated value if it is a customData generator, the value itself otherwise.
+func (v *Visit) evaluateContextValue(value interface{}) (interface{}, error) {
+	if values, ok := value.([]interface{}); ok {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		value = values[rand.Intn(len(values))]
+	}
+	return v.evaluateCustomDataValue(value)
+}
+
+// SetContext Evaluate the context values and add them to the context of the visit, sent
+// with the following queries.
+func (v *Visit) SetContext(context map[string]interface{}) {
+	if len(context) == 0 {
+		return
+	}
+	if v.Context == nil {
+		v.Context = make(map[string]interface{})
+	}
+	// Keys are sorted so random values are the same for a given seed.
+	for _, k := range sortedKeys(context) {
+		value, err := v.evaluateContextValue(context[k])
+		if err != nil {
+			Warning.Printf("Cannot generate context %s : %v", k, err)
+			continue
+		}
+		v.Context[k] = value
+	}
+	if v.LastQuery != nil {
+		v.LastQuery.Context = v.Context
+	}
+}
@@ -0,0 +1,58 @@
+package scenariolib_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/scenariolib"
+	ua "github.com/coveooss/go-coveo/analytics"
+)
+
+func TestVisitContext(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	path := writeTestConfig(t, `{
+		"searchHub": "CommunityHub",
+		"context": {"role": ["admin"], "country": "CA"},
+		"scenarios": [{
+			"name": "agent",
+			"weight": 1,
+			"searchHub": "AgentHub",
+			"tab": "Cases",
+			"context": {"role": "agent"},
+			"events": []
+		}]
+	}`)
+	defer os.Remove(path)
+
+	conf, err := scenariolib.NewConfigFromPath(path)
+	ok(t, err)
+
+	// The search hub is the default originLevel1.
+	equals(t, "CommunityHub", conf.DefaultOriginLevel1)
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	equals(t, "CommunityHub", v.LastQuery.SearchHub)
+	equals(t, map[string]interface{}{"role": "admin", "country": "CA"}, v.LastQuery.Context)
+
+	v, err = scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf.ForScenario(conf.Scenarios[0]))
+	ok(t, err)
+	v.SetupGeneral()
+
+	equals(t, "AgentHub", v.LastQuery.SearchHub)
+	equals(t, "AgentHub", v.OriginLevel1)
+	equals(t, "Cases", v.LastQuery.Tab)
+	equals(t, "Cases", v.OriginLevel2)
+	equals(t, map[string]interface{}{"role": "agent", "country": "CA"}, v.LastQuery.Context)
+
+	// Event level context is added to the visit context.
+	v.SetContext(map[string]interface{}{"product": []interface{}{"tv"}})
+	equals(t, "tv", v.LastQuery.Context["product"])
+
+	evt := ua.NewSearchEvent()
+	v.DecorateCustomMetadata(evt.ActionEvent, nil)
+	equals(t, "agent", evt.CustomData["context_role"])
+	equals(t, "tv", evt.CustomData["context_product"])
+}
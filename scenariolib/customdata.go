@@ -0,0 +1,191 @@
+package scenariolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// GENERATORRANDOMINT A random integer between min and max (inclusive).
+	GENERATORRANDOMINT string = "randomInt"
+	// GENERATORRANDOMFLOAT A random float between min and max.
+	GENERATORRANDOMFLOAT string = "randomFloat"
+	// GENERATORNORMAL A normally distributed number of mean and stdDev, clamped to [min, max] when specified.
+	GENERATORNORMAL string = "normal"
+	// GENERATORDATE A date between now + min days and now + max days.
+	GENERATORDATE string = "date"
+	// GENERATORUUID A random UUID.
+	GENERATORUUID string = "uuid"
+	// GENERATORVISIT A property of the visit (language, username, ip, anonymous, originLevel1, originLevel2, originLevel3, referrer).
+	GENERATORVISIT string = "visit"
+	// GENERATORVARIABLE A visit variable captured by a previous event.
+	GENERATORVARIABLE string = "variable"
+	// GENERATORCLICKEDRESULT A field of the last clicked result, its title if no field is specified.
+	GENERATORCLICKEDRESULT string = "clickedResult"
+	// DEFAULTDATEFORMAT The format of generated dates when not specified.
+	DEFAULTDATEFORMAT string = time.RFC3339
+)
+
+// CustomDataGenerator A customData value computed when the event is sent, described in
+// the JSON as an object instead of a static value, for example {"type": "randomInt", "min": 1, "max": 10}.
+type CustomDataGenerator struct {
+	Type     string   `json:"type"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Mean     float64  `json:"mean,omitempty"`
+	StdDev   float64  `json:"stdDev,omitempty"`
+	Decimals int      `json:"decimals,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Property string   `json:"property,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Field    string   `json:"field,omitempty"`
+}
+
+// evaluateCustomDataValue Returns the value to send for a customData value, static values
+// are returned as is and generators are computed. An object is a generator only when its
+// type is one of the generator types.
+func (v *Visit) evaluateCustomDataValue(value interface{}) (interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value, nil
+	}
+	if generatorType, ok := object["type"].(string); !ok || !isGeneratorType(generatorType) {
+		return value, nil
+	}
+	generator := &CustomDataGenerator{}
+	bytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(bytes, generator); err != nil {
+		return nil, err
+	}
+	return generator.generate(v)
+}
+
+// isGeneratorType Returns true if the type is one of the customData generators.
+func isGeneratorType(generatorType string) bool {
+	switch generatorType {
+	case GENERATORRANDOMINT, GENERATORRANDOMFLOAT, GENERATORNORMAL, GENERATORDATE, GENERATORUUID,
+		GENERATORVISIT, GENERATORVARIABLE, GENERATORCLICKEDRESULT:
+		return true
+	}
+	return false
+}
+
+// generate Compute a value from the generator for the current visit.
+func (generator *CustomDataGenerator) generate(v *Visit) (interface{}, error) {
+	switch generator.Type {
+	case GENERATORRANDOMINT:
+		if generator.Min == nil || generator.Max == nil || *generator.Max < *generator.Min {
+			return nil, fmt.Errorf("%s needs a min and a max >= min", generator.Type)
+		}
+		min, max := int(*generator.Min), int(*generator.Max)
+		return min + rand.Intn(max-min+1), nil
+
+	case GENERATORRANDOMFLOAT:
+		if generator.Min == nil || generator.Max == nil || *generator.Max < *generator.Min {
+			return nil, fmt.Errorf("%s needs a min and a max >= min", generator.Type)
+		}
+		return roundTo(*generator.Min+rand.Float64()*(*generator.Max-*generator.Min), generator.Decimals), nil
+
+	case GENERATORNORMAL:
+		value := rand.NormFloat64()*generator.StdDev + generator.Mean
+		if generator.Min != nil {
+			value = math.Max(value, *generator.Min)
+		}
+		if generator.Max != nil {
+			value = math.Min(value, *generator.Max)
+		}
+		return roundTo(value, generator.Decimals), nil
+
+	case GENERATORDATE:
+		minDays, maxDays := 0.0, 0.0
+		if generator.Min != nil {
+			minDays = *generator.Min
+		}
+		if generator.Max != nil {
+			maxDays = *generator.Max
+		}
+		if maxDays < minDays {
+			return nil, fmt.Errorf("%s needs a max >= min", generator.Type)
+		}
+		days := minDays + rand.Float64()*(maxDays-minDays)
+		format := generator.Format
+		if format == "" {
+			format = DEFAULTDATEFORMAT
+		}
+		return time.Now().Add(time.Duration(days * float64(24*time.Hour))).Format(format), nil
+
+	case GENERATORUUID:
+		return randomUUID(), nil
+
+	case GENERATORVISIT:
+		return v.visitProperty(generator.Property)
+
+	case GENERATORVARIABLE:
+		value, ok := v.Variables[generator.Name]
+		if !ok {
+			return nil, fmt.Errorf("Variable %s is not defined in this visit", generator.Name)
+		}
+		return value, nil
+
+	case GENERATORCLICKEDRESULT:
+		if v.LastClickedResult == nil {
+			return nil, fmt.Errorf("%s needs a clicked result", generator.Type)
+		}
+		if generator.Field == "" {
+			return v.LastClickedResult.Title, nil
+		}
+		rawValue := getFieldValueFromRaw(v.LastClickedResult.Raw, strings.TrimPrefix(generator.Field, "@"))
+		if rawValue == nil {
+			return nil, fmt.Errorf("Field %s does not exist on the clicked result", generator.Field)
+		}
+		return rawValue, nil
+	}
+	return nil, fmt.Errorf("CustomData generator type %s is not supported", generator.Type)
+}
+
+// visitProperty Returns a property of the visit by name.
+func (v *Visit) visitProperty(property string) (interface{}, error) {
+	switch property {
+	case "language":
+		return v.Language, nil
+	case "username":
+		return v.Username, nil
+	case "ip":
+		return v.IP, nil
+	case "anonymous":
+		return v.Anonymous, nil
+	case "originLevel1":
+		return v.OriginLevel1, nil
+	case "originLevel2":
+		return v.OriginLevel2, nil
+	case "originLevel3":
+		return v.OriginLevel3, nil
+	case "referrer":
+		return v.Referrer, nil
+	}
+	return nil, fmt.Errorf("Visit property %s is not supported", property)
+}
+
+// roundTo Round a value to a number of decimals.
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
+
+// randomUUID Returns a random version 4 UUID.
+func randomUUID() string {
+	bytes := make([]byte, 16)
+	for i := range bytes {
+		bytes[i] = byte(rand.Intn(256))
+	}
+	bytes[6] = (bytes[6] & 0x0f) | 0x40
+	bytes[8] = (bytes[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", bytes[0:4], bytes[4:6], bytes[6:8], bytes[8:10], bytes[10:])
+}
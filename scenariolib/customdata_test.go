@@ -0,0 +1,47 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/coveo/uabot/scenariolib"
+	ua "github.com/coveooss/go-coveo/analytics"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestDecorateCustomMetadataGenerators(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	var testCustomDataJson = []byte(`{
+		"static": "value",
+		"cartValue": {"type": "randomFloat", "min": 10, "max": 20, "decimals": 2},
+		"accountAge": {"type": "randomInt", "min": 3, "max": 3},
+		"score": {"type": "normal", "mean": 50, "stdDev": 10, "min": 50, "max": 50},
+		"id": {"type": "uuid"},
+		"language": {"type": "visit", "property": "language"},
+		"author": {"type": "clickedResult", "field": "@author"},
+		"product": {"type": "book", "sku": "b1"}
+	}`)
+	customData := map[string]interface{}{}
+	err := json.Unmarshal(testCustomDataJson, &customData)
+	ok(t, err)
+
+	v := &scenariolib.Visit{
+		Config:            &scenariolib.Config{},
+		Language:          "fr",
+		LastClickedResult: &search.Result{Raw: map[string]interface{}{"author": "Jane"}},
+	}
+	evt := ua.NewSearchEvent()
+	v.DecorateCustomMetadata(evt.ActionEvent, customData)
+
+	equals(t, "value", evt.CustomData["static"])
+	cartValue, isFloat := evt.CustomData["cartValue"].(float64)
+	assert(t, isFloat && cartValue >= 10 && cartValue <= 20, "Expected cartValue to be between 10 and 20, got %v", evt.CustomData["cartValue"])
+	equals(t, 3, evt.CustomData["accountAge"])
+	equals(t, 50.0, evt.CustomData["score"])
+	assert(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(evt.CustomData["id"].(string)), "Expected id to be a UUID, got %v", evt.CustomData["id"])
+	equals(t, "fr", evt.CustomData["language"])
+	equals(t, "Jane", evt.CustomData["author"])
+	equals(t, map[string]interface{}{"type": "book", "sku": "b1"}, evt.CustomData["product"])
+}
@@ -0,0 +1,181 @@
+package scenariolib
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DWELLCONSTANT The user always stays the mean time on a document.
+	DWELLCONSTANT string = "constant"
+	// DWELLUNIFORM The user stays between the min and the max time on a document.
+	DWELLUNIFORM string = "uniform"
+	// DWELLEXPONENTIAL The user mostly stays a short time on a document, sometimes a long time.
+	DWELLEXPONENTIAL string = "exponential"
+	// DWELLLOGNORMAL The user mostly stays close to the mean time on a document, with a long tail.
+	DWELLLOGNORMAL string = "lognormal"
+	// DEFAULTDWELLMEAN The mean time in seconds the user stays on a document when not specified.
+	DEFAULTDWELLMEAN float64 = 30
+	// DEFAULTDWELLEVENTTYPE The type of the custom event sent after the dwell when not specified.
+	DEFAULTDWELLEVENTTYPE string = "engagement"
+	// DEFAULTDWELLEVENTVALUE The value of the custom event sent after the dwell when not specified.
+	DEFAULTDWELLEVENTVALUE string = "dwell"
+	// DEFAULTMAXPOGOSTICKS The maximum number of returns to the results after a click when not specified.
+	DEFAULTMAXPOGOSTICKS int = 1
+)
+
+// Dwell How long the user stays on a clicked document, and the engagement events sent after.
+// Distribution       The distribution of the dwell time, constant, uniform, exponential (default) or lognormal
+// Mean               The mean dwell time in seconds
+// StdDev             The standard deviation of the dwell time in seconds, for the lognormal distribution
+// Min, Max           The bounds of the dwell time in seconds, no maximum when 0
+// EventType          The type of the custom event sent with the dwell time
+// EventValue         The value of the custom event sent with the dwell time
+// SendView           Send a view event on the clicked document
+// PageViewField      The field of the view event, the defaultPageViewField of the config when empty
+// ContentType        The content type of the view event
+// PogoStickThreshold The user returns to the results and clicks another one when the dwell is
+// shorter, never when 0
+// MaxPogoSticks      The maximum number of returns to the results after a click
+type Dwell struct {
+	Distribution       string  `json:"distribution,omitempty"`
+	Mean               float64 `json:"mean,omitempty"`
+	StdDev             float64 `json:"stdDev,omitempty"`
+	Min                float64 `json:"min,omitempty"`
+	Max                float64 `json:"max,omitempty"`
+	EventType          string  `json:"eventType,omitempty"`
+	EventValue         string  `json:"eventValue,omitempty"`
+	SendView           bool    `json:"sendView,omitempty"`
+	PageViewField      string  `json:"pageViewField,omitempty"`
+	ContentType        string  `json:"contentType,omitempty"`
+	PogoStickThreshold float64 `json:"pogoStickThreshold,omitempty"`
+	MaxPogoSticks      int     `json:"maxPogoSticks,omitempty"`
+}
+
+// IsValid Validate the distribution and the times of the dwell.
+func (dwell *Dwell) IsValid() (bool, string) {
+	switch dwell.Distribution {
+	case "", DWELLCONSTANT, DWELLEXPONENTIAL, DWELLLOGNORMAL:
+	case DWELLUNIFORM:
+		if dwell.Max == 0 {
+			return false, "A uniform dwell needs a max."
+		}
+	default:
+		return false, fmt.Sprintf("Dwell distribution %s is not supported.", dwell.Distribution)
+	}
+	if dwell.Mean < 0 || dwell.StdDev < 0 || dwell.Min < 0 || dwell.Max < 0 || dwell.PogoStickThreshold < 0 {
+		return false, "The times of a dwell must be positive."
+	}
+	if dwell.Max > 0 && dwell.Max < dwell.Min {
+		return false, "The max of a dwell must be greater than its min."
+	}
+	if dwell.MaxPogoSticks < 0 {
+		return false, "maxPogoSticks must be a positive integer."
+	}
+	return true, ""
+}
+
+// Sample Returns a random dwell time in seconds, within the bounds.
+func (dwell *Dwell) Sample() float64 {
+	mean := dwell.Mean
+	if mean == 0 {
+		mean = DEFAULTDWELLMEAN
+	}
+
+	var seconds float64
+	switch dwell.Distribution {
+	case DWELLCONSTANT:
+		seconds = mean
+	case DWELLUNIFORM:
+		seconds = dwell.Min + rand.Float64()*(dwell.Max-dwell.Min)
+	case DWELLLOGNORMAL:
+		stdDev := dwell.StdDev
+		if stdDev == 0 {
+			stdDev = mean
+		}
+		// The parameters of the normal distribution giving this mean and standard deviation.
+		sigma := math.Sqrt(math.Log(1 + stdDev*stdDev/(mean*mean)))
+		mu := math.Log(mean) - sigma*sigma/2
+		seconds = math.Exp(mu + sigma*rand.NormFloat64())
+	default:
+		seconds = rand.ExpFloat64() * mean
+	}
+
+	seconds = math.Max(seconds, dwell.Min)
+	if dwell.Max > 0 {
+		seconds = math.Min(seconds, dwell.Max)
+	}
+	return seconds
+}
+
+// dwell Returns the dwell of the event, or the one of the config.
+func (v *Visit) dwell(eventDwell *Dwell) *Dwell {
+	if eventDwell != nil {
+		return eventDwell
+	}
+	return v.Config.Dwell
+}
+
+// dwellOn Simulate the user staying on the result clicked at the rank, then send the
+// engagement events. The user returns to the results and clicks another one when the dwell
+// is short, at most MaxPogoSticks times after the first click.
+func (v *Visit) dwellOn(dwell *Dwell, rank int, quickview bool, customData map[string]interface{}, pogoSticks int) error {
+	if dwell.SendView {
+		field := dwell.PageViewField
+		if field == "" {
+			field = v.Config.DefaultPageViewField
+		}
+		view := &ViewEvent{ClickRank: rank, PageViewField: field, ContentType: dwell.ContentType, CustomData: customData}
+		if err := view.send(v); err != nil {
+			return err
+		}
+	}
+
+	seconds := dwell.Sample()
+	Info.Printf("User stays %.1f seconds on the result at rank %d", seconds, rank+1)
+	if v.WaitBetweenActions {
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+	}
+
+	pogoStick := dwell.PogoStickThreshold > 0 && seconds < dwell.PogoStickThreshold
+	engagement := make(map[string]interface{})
+	for k, value := range customData {
+		engagement[k] = value
+	}
+	engagement["dwellSeconds"] = math.Round(seconds*10) / 10
+	engagement["documentPosition"] = v.LastQuery.FirstResult + rank + 1
+	engagement["documentUri"] = v.LastResponse.Results[rank].URI
+	engagement["pogoStick"] = pogoStick
+	eventType, eventValue := dwell.EventType, dwell.EventValue
+	if eventType == "" {
+		eventType = DEFAULTDWELLEVENTTYPE
+	}
+	if eventValue == "" {
+		eventValue = DEFAULTDWELLEVENTVALUE
+	}
+	if err := v.sendCustomEvent(eventValue, eventType, engagement); err != nil {
+		return err
+	}
+
+	maxPogoSticks := dwell.MaxPogoSticks
+	if maxPogoSticks == 0 {
+		maxPogoSticks = DEFAULTMAXPOGOSTICKS
+	}
+	numberOfResults := len(v.LastResponse.Results)
+	if !pogoStick || pogoSticks >= maxPogoSticks || numberOfResults < 2 {
+		return nil
+	}
+
+	// The user goes back to the results and clicks on another one.
+	next := rand.Intn(numberOfResults - 1)
+	if next >= rank {
+		next++
+	}
+	Info.Printf("User returns to the results after %.1f seconds", seconds)
+	if err := v.sendClickEvent(next, quickview, customData); err != nil {
+		return err
+	}
+	return v.dwellOn(dwell, next, quickview, customData, pogoSticks+1)
+}
@@ -0,0 +1,101 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestDwellInvalid(t *testing.T) {
+	var testDwells = []scenariolib.Dwell{
+		{Distribution: "gaussian"},
+		{Distribution: "uniform", Min: 5},
+		{Mean: -1},
+		{Min: 10, Max: 5},
+		{MaxPogoSticks: -1},
+	}
+
+	for _, dwell := range testDwells {
+		valid, _ := dwell.IsValid()
+		assert(t, !valid, "Expected dwell %v to be invalid.", dwell)
+	}
+}
+
+func TestDwellSample(t *testing.T) {
+	equals(t, 12.0, (&scenariolib.Dwell{Distribution: "constant", Mean: 12}).Sample())
+	equals(t, 30.0, (&scenariolib.Dwell{Distribution: "constant"}).Sample())
+
+	for _, dwell := range []*scenariolib.Dwell{
+		{Distribution: "uniform", Min: 5, Max: 10},
+		{Distribution: "exponential", Mean: 100, Min: 5, Max: 10},
+		{Distribution: "lognormal", Mean: 7, StdDev: 20, Min: 5, Max: 10},
+	} {
+		for i := 0; i < 100; i++ {
+			seconds := dwell.Sample()
+			assert(t, seconds >= 5 && seconds <= 10, "Expected a %s dwell between 5 and 10 seconds, was %v", dwell.Distribution, seconds)
+		}
+	}
+}
+
+func TestClickEventDwell(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// Keep the events sent to the analytics, by type.
+	events := map[string][]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		events[req.URL.Path] = append(events[req.URL.Path], body)
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.Dwell = &scenariolib.Dwell{Distribution: "constant", Mean: 42, SendView: true, PageViewField: "permanentid"}
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.WaitBetweenActions = false
+	v.LastResponse = &search.Response{TotalCount: 3, Results: []search.Result{
+		{URI: "uri1", Raw: map[string]interface{}{"urihash": "hash1", "permanentid": "id1"}},
+		{URI: "uri2", Raw: map[string]interface{}{"urihash": "hash2", "permanentid": "id2"}},
+		{URI: "uri3", Raw: map[string]interface{}{"urihash": "hash3", "permanentid": "id3"}},
+	}}
+
+	// The dwell of the config sends a view on the clicked document, then the dwell time.
+	ok(t, (&scenariolib.ClickEvent{ClickRank: 1, Probability: 1}).Execute(v))
+	equals(t, 1, len(events[defaults.ANALYTICS_REST_PATH+"click/"]))
+	equals(t, 1, len(events[defaults.ANALYTICS_REST_PATH+"view/"]))
+	equals(t, "id2", events[defaults.ANALYTICS_REST_PATH+"view/"][0]["contentIdValue"])
+	custom := events[defaults.ANALYTICS_REST_PATH+"custom/"]
+	equals(t, 1, len(custom))
+	equals(t, "engagement", custom[0]["eventType"])
+	equals(t, "dwell", custom[0]["eventValue"])
+	customData := custom[0]["customData"].(map[string]interface{})
+	equals(t, 42.0, customData["dwellSeconds"])
+	equals(t, 2.0, customData["documentPosition"])
+	equals(t, false, customData["pogoStick"])
+
+	// A short dwell of the event makes the user return to the results and click other ones.
+	events = map[string][]map[string]interface{}{}
+	dwell := &scenariolib.Dwell{Distribution: "constant", Mean: 2, PogoStickThreshold: 10, MaxPogoSticks: 2}
+	ok(t, (&scenariolib.ClickEvent{ClickRank: 0, Probability: 1, Dwell: dwell}).Execute(v))
+	clicks := events[defaults.ANALYTICS_REST_PATH+"click/"]
+	equals(t, 3, len(clicks))
+	assert(t, clicks[1]["documentPosition"] != clicks[0]["documentPosition"], "Expected the user to click on another result.")
+	assert(t, clicks[2]["documentPosition"] != clicks[1]["documentPosition"], "Expected the user to click on another result.")
+	equals(t, 3, len(events[defaults.ANALYTICS_REST_PATH+"custom/"]))
+	equals(t, 0, len(events[defaults.ANALYTICS_REST_PATH+"view/"]))
+	equals(t, true, events[defaults.ANALYTICS_REST_PATH+"custom/"][2]["customData"].(map[string]interface{})["pogoStick"])
+}
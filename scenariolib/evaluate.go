@@ -0,0 +1,302 @@
+package scenariolib
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// DEFAULTEVALUATIONDEPTH The number of results evaluated for each query when not specified.
+	DEFAULTEVALUATIONDEPTH int = 10
+	// REPORTFORMATTEXT A report in aligned columns of text.
+	REPORTFORMATTEXT string = "text"
+	// REPORTFORMATJSON A report in JSON, which can be read back to compare reports.
+	REPORTFORMATJSON string = "json"
+	// REPORTFORMATCSV A report in CSV, one line per query.
+	REPORTFORMATCSV string = "csv"
+)
+
+// Metrics The relevance metrics of the first K results.
+// NDCG      The normalized discounted cumulative gain, the grades of the results against the best grades
+// MRR       The reciprocal rank of the first relevant result, the mean reciprocal rank of several queries
+// Precision The part of the results that are relevant
+type Metrics struct {
+	NDCG      float64 `json:"ndcg"`
+	MRR       float64 `json:"mrr"`
+	Precision float64 `json:"precision"`
+}
+
+// QueryEvaluation The metrics of a judged query.
+type QueryEvaluation struct {
+	Query           string `json:"query"`
+	NumberOfResults int    `json:"numberOfResults"`
+	Metrics
+}
+
+// EvaluationReport The metrics of every judged query of a pipeline, and their mean.
+type EvaluationReport struct {
+	Pipeline string            `json:"pipeline"`
+	K        int               `json:"k"`
+	Queries  []QueryEvaluation `json:"queries"`
+	Metrics
+}
+
+// QueryComparison The metrics of a query in two reports, and the candidate minus the baseline.
+type QueryComparison struct {
+	Query     string  `json:"query"`
+	Baseline  Metrics `json:"baseline"`
+	Candidate Metrics `json:"candidate"`
+	Delta     Metrics `json:"delta"`
+}
+
+// ReportComparison The comparison of the queries evaluated in two reports.
+type ReportComparison struct {
+	BaselinePipeline  string            `json:"baselinePipeline"`
+	CandidatePipeline string            `json:"candidatePipeline"`
+	K                 int               `json:"k"`
+	Queries           []QueryComparison `json:"queries"`
+	Baseline          Metrics           `json:"baseline"`
+	Candidate         Metrics           `json:"candidate"`
+	Delta             Metrics           `json:"delta"`
+}
+
+// Evaluate Search for every judged query of the config with the pipeline, the pipeline of the
+// config when empty, and compute the metrics of their first k results. No analytics are sent.
+func (c *Config) Evaluate(client search.Client, pipeline string, k int) (*EvaluationReport, error) {
+	if c.Judgments == nil {
+		return nil, errors.New("Cannot evaluate without judgments")
+	}
+	if k <= 0 {
+		k = DEFAULTEVALUATIONDEPTH
+	}
+	if pipeline == "" {
+		pipeline = c.Pipeline
+	}
+
+	report := &EvaluationReport{Pipeline: pipeline, K: k, Queries: []QueryEvaluation{}}
+	seen := make(map[string]bool)
+	for _, query := range c.Judgments.queries(c.GoodQueries) {
+		if seen[query] {
+			continue
+		}
+		seen[query] = true
+
+		resp, err := client.Query(search.Query{
+			Q:               query,
+			NumberOfResults: k,
+			CQ:              c.GlobalFilter,
+			AQ:              c.QueryDefaults.AQ,
+			Pipeline:        pipeline,
+			SearchHub:       c.SearchHub,
+			Tab:             c.Tab,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Cannot evaluate query %s : %v", query, err)
+		}
+		results := resp.Results
+		if len(results) > k {
+			results = results[:k]
+		}
+		Info.Printf("Evaluating query %s with %d results", query, resp.TotalCount)
+		report.Queries = append(report.Queries, QueryEvaluation{
+			Query:           query,
+			NumberOfResults: resp.TotalCount,
+			Metrics:         c.Judgments.Find(query).metrics(results, k),
+		})
+	}
+
+	metrics := make([]Metrics, len(report.Queries))
+	for i, evaluation := range report.Queries {
+		metrics[i] = evaluation.Metrics
+	}
+	report.Metrics = meanMetrics(metrics)
+	return report, nil
+}
+
+// metrics Returns the metrics of the results, the unjudged documents are not relevant. A judged
+// document matching several results, by title or pattern, only counts at its best rank.
+func (judged *JudgedQuery) metrics(results []search.Result, k int) (metrics Metrics) {
+	dcg, relevant := 0.0, 0
+	credited := make(map[*JudgedDocument]bool)
+	for i, result := range results {
+		document := judged.document(result)
+		if document == nil || document.Grade <= 0 || credited[document] {
+			continue
+		}
+		credited[document] = true
+		dcg += gain(document.Grade, i)
+		relevant++
+		if metrics.MRR == 0 {
+			metrics.MRR = 1 / float64(i+1)
+		}
+	}
+	metrics.Precision = float64(relevant) / float64(k)
+
+	grades := []int{}
+	for _, document := range judged.Documents {
+		grades = append(grades, document.Grade)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(grades)))
+	idcg := 0.0
+	for i := 0; i < len(grades) && i < k; i++ {
+		idcg += gain(grades[i], i)
+	}
+	if idcg > 0 {
+		metrics.NDCG = dcg / idcg
+	}
+	return
+}
+
+// gain The discounted gain of a grade at a rank.
+func gain(grade int, rank int) float64 {
+	return (math.Pow(2, float64(grade)) - 1) / math.Log2(float64(rank+2))
+}
+
+func meanMetrics(metrics []Metrics) (mean Metrics) {
+	if len(metrics) == 0 {
+		return
+	}
+	for _, m := range metrics {
+		mean.NDCG += m.NDCG
+		mean.MRR += m.MRR
+		mean.Precision += m.Precision
+	}
+	n := float64(len(metrics))
+	return Metrics{NDCG: mean.NDCG / n, MRR: mean.MRR / n, Precision: mean.Precision / n}
+}
+
+func (m Metrics) minus(other Metrics) Metrics {
+	return Metrics{NDCG: m.NDCG - other.NDCG, MRR: m.MRR - other.MRR, Precision: m.Precision - other.Precision}
+}
+
+// NewEvaluationReportFromPath Read a report saved in JSON.
+func NewEvaluationReportFromPath(path string) (*EvaluationReport, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read report : %v", err)
+	}
+	report := &EvaluationReport{}
+	if err = json.Unmarshal(content, report); err != nil {
+		return nil, fmt.Errorf("Cannot parse report %s : %v", path, err)
+	}
+	return report, nil
+}
+
+// CompareReports Compare the queries evaluated in both reports, the means are computed on
+// these queries only. The reports must evaluate the same number of results.
+func CompareReports(baseline, candidate *EvaluationReport) (*ReportComparison, error) {
+	if baseline.K != candidate.K {
+		return nil, fmt.Errorf("Cannot compare reports of %d and %d results", baseline.K, candidate.K)
+	}
+	candidateMetrics := make(map[string]Metrics)
+	for _, evaluation := range candidate.Queries {
+		candidateMetrics[evaluation.Query] = evaluation.Metrics
+	}
+
+	comparison := &ReportComparison{
+		BaselinePipeline:  baseline.Pipeline,
+		CandidatePipeline: candidate.Pipeline,
+		K:                 baseline.K,
+		Queries:           []QueryComparison{},
+	}
+	baselines, candidates := []Metrics{}, []Metrics{}
+	for _, evaluation := range baseline.Queries {
+		metrics, ok := candidateMetrics[evaluation.Query]
+		if !ok {
+			continue
+		}
+		comparison.Queries = append(comparison.Queries, QueryComparison{
+			Query:     evaluation.Query,
+			Baseline:  evaluation.Metrics,
+			Candidate: metrics,
+			Delta:     metrics.minus(evaluation.Metrics),
+		})
+		baselines = append(baselines, evaluation.Metrics)
+		candidates = append(candidates, metrics)
+	}
+	comparison.Baseline = meanMetrics(baselines)
+	comparison.Candidate = meanMetrics(candidates)
+	comparison.Delta = comparison.Candidate.minus(comparison.Baseline)
+	return comparison, nil
+}
+
+// Write Write the report in the format, text, json or csv.
+func (report *EvaluationReport) Write(w io.Writer, format string) error {
+	k := strconv.Itoa(report.K)
+	header := []string{"query", "numberOfResults", "ndcg@" + k, "mrr", "precision@" + k}
+	rows := [][]string{}
+	for _, evaluation := range report.Queries {
+		rows = append(rows, append([]string{evaluation.Query, strconv.Itoa(evaluation.NumberOfResults)}, evaluation.Metrics.columns()...))
+	}
+	total := append([]string{"(mean)", ""}, report.Metrics.columns()...)
+	return writeReport(w, format, report, fmt.Sprintf("Pipeline %s, %d queries", report.Pipeline, len(report.Queries)), header, rows, total)
+}
+
+// Write Write the comparison in the format, text, json or csv.
+func (comparison *ReportComparison) Write(w io.Writer, format string) error {
+	k := strconv.Itoa(comparison.K)
+	header := []string{"query"}
+	for _, column := range []string{"baseline", "candidate", "delta"} {
+		header = append(header, column+" ndcg@"+k, column+" mrr", column+" precision@"+k)
+	}
+	rows := [][]string{}
+	for _, query := range comparison.Queries {
+		row := append([]string{query.Query}, query.Baseline.columns()...)
+		rows = append(rows, append(append(row, query.Candidate.columns()...), query.Delta.columns()...))
+	}
+	total := append([]string{"(mean)"}, comparison.Baseline.columns()...)
+	total = append(append(total, comparison.Candidate.columns()...), comparison.Delta.columns()...)
+	title := fmt.Sprintf("Pipeline %s (baseline) against %s (candidate), %d queries", comparison.BaselinePipeline, comparison.CandidatePipeline, len(comparison.Queries))
+	return writeReport(w, format, comparison, title, header, rows, total)
+}
+
+func (m Metrics) columns() []string {
+	return []string{
+		strconv.FormatFloat(m.NDCG, 'f', 4, 64),
+		strconv.FormatFloat(m.MRR, 'f', 4, 64),
+		strconv.FormatFloat(m.Precision, 'f', 4, 64),
+	}
+}
+
+// writeReport Write the value in JSON, or its rows in CSV or in text with a title.
+func writeReport(w io.Writer, format string, value interface{}, title string, header []string, rows [][]string, total []string) error {
+	switch format {
+	case REPORTFORMATJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case REPORTFORMATCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(append(append([][]string{header}, rows...), total)); err != nil {
+			return err
+		}
+		return writer.Error()
+	case REPORTFORMATTEXT, "":
+		if _, err := fmt.Fprintln(w, title); err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, row := range append(append([][]string{header}, rows...), total) {
+			for i, column := range row {
+				if i > 0 {
+					fmt.Fprint(writer, "\t")
+				}
+				fmt.Fprint(writer, column)
+			}
+			fmt.Fprintln(writer, "\t")
+		}
+		return writer.Flush()
+	default:
+		return fmt.Errorf("Report format %s is not supported", format)
+	}
+}
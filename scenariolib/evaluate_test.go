@@ -0,0 +1,141 @@
+package scenariolib_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func evaluationConfig(t *testing.T) *scenariolib.Config {
+	conf := &scenariolib.Config{GoodQueries: []string{"vpn windows", "other"}, Pipeline: "default"}
+	ok(t, json.Unmarshal([]byte(`{"maxGrade": 2, "queries": [
+		{"query": "password", "documents": [{"grade": 2, "urihash": "hash1"}, {"grade": 1, "urihash": "hash3"}]},
+		{"queryPattern": "^vpn", "documents": [{"grade": 2, "urihash": "hash9"}]}
+	]}`), &conf.Judgments))
+	valid, message := conf.Judgments.IsValid()
+	assert(t, valid, "Expected judgments to be valid, was false with error: %s", message)
+	return conf
+}
+
+func TestEvaluate(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// The pipeline "ml" ranks the most relevant document first.
+	queries := []*search.Query{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		query := &search.Query{}
+		json.NewDecoder(req.Body).Decode(query)
+		queries = append(queries, query)
+		if query.Pipeline == "ml" {
+			rw.Write([]byte(`{"totalCount": 3, "results": [{"raw": {"urihash": "hash1"}}, {"raw": {"urihash": "hash2"}}, {"raw": {"urihash": "hash3"}}]}`))
+			return
+		}
+		rw.Write([]byte(`{"totalCount": 3, "results": [{"raw": {"urihash": "hash2"}}, {"raw": {"urihash": "hash3"}}, {"raw": {"urihash": "hash1"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := search.NewClient(search.Config{Token: "bot.searchToken", Endpoint: server.URL + defaults.SEARCH_REST_PATH})
+	ok(t, err)
+	conf := evaluationConfig(t)
+
+	baseline, err := conf.Evaluate(client, "", 2)
+	ok(t, err)
+	equals(t, 2, len(queries))
+	equals(t, "default", queries[0].Pipeline)
+	equals(t, 2, queries[0].NumberOfResults)
+
+	// The judged query, then the good query matching the judged pattern.
+	equals(t, "password", baseline.Queries[0].Query)
+	equals(t, "vpn windows", baseline.Queries[1].Query)
+
+	// Only hash3 is relevant in the first 2 results, at rank 2.
+	idcg := 3 + 1/math.Log2(3)
+	assert(t, math.Abs(baseline.Queries[0].NDCG-(1/math.Log2(3))/idcg) < 1e-9, "Unexpected NDCG %v", baseline.Queries[0].NDCG)
+	equals(t, 0.5, baseline.Queries[0].MRR)
+	equals(t, 0.5, baseline.Queries[0].Precision)
+	equals(t, scenariolib.Metrics{}, baseline.Queries[1].Metrics)
+	equals(t, 0.25, baseline.MRR)
+
+	candidate, err := conf.Evaluate(client, "ml", 2)
+	ok(t, err)
+	equals(t, 1.0, candidate.Queries[0].MRR)
+
+	comparison, err := scenariolib.CompareReports(baseline, candidate)
+	ok(t, err)
+	equals(t, 2, len(comparison.Queries))
+	equals(t, 0.5, comparison.Queries[0].Delta.MRR)
+	equals(t, 0.25, comparison.Delta.MRR)
+	assert(t, comparison.Delta.NDCG > 0, "Expected the candidate to have a better NDCG.")
+
+	_, err = scenariolib.CompareReports(baseline, &scenariolib.EvaluationReport{K: 10})
+	notok(t, err)
+}
+
+func TestEvaluateDocumentMatchingSeveralResults(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// The title judgment "Rocky" matches both "Rocky" and "Rocky II".
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`{"totalCount": 3, "results": [{"title": "Rocky II"}, {"title": "Rocky"}, {"title": "Creed"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := search.NewClient(search.Config{Token: "bot.searchToken", Endpoint: server.URL + defaults.SEARCH_REST_PATH})
+	ok(t, err)
+	conf := &scenariolib.Config{}
+	ok(t, json.Unmarshal([]byte(`{"queries": [
+		{"query": "balboa", "documents": [{"grade": 3, "title": "Rocky"}, {"grade": 1, "title": "Creed"}]}
+	]}`), &conf.Judgments))
+	valid, message := conf.Judgments.IsValid()
+	assert(t, valid, "Expected judgments to be valid, was false with error: %s", message)
+
+	report, err := conf.Evaluate(client, "", 3)
+	ok(t, err)
+	metrics := report.Queries[0].Metrics
+	assert(t, metrics.NDCG <= 1, "Expected NDCG to be at most 1, was %v", metrics.NDCG)
+
+	// The Rocky judgment only counts at rank 1, Creed at rank 3.
+	idcg := 7 + 1/math.Log2(3)
+	assert(t, math.Abs(metrics.NDCG-(7+1/math.Log2(4))/idcg) < 1e-9, "Unexpected NDCG %v", metrics.NDCG)
+	assert(t, math.Abs(metrics.Precision-2.0/3) < 1e-9, "Unexpected precision %v", metrics.Precision)
+	equals(t, 1.0, metrics.MRR)
+}
+
+func TestEvaluationReportWrite(t *testing.T) {
+	report := &scenariolib.EvaluationReport{Pipeline: "ml", K: 5, Queries: []scenariolib.QueryEvaluation{
+		{Query: "vpn, setup", NumberOfResults: 12, Metrics: scenariolib.Metrics{NDCG: 0.5, MRR: 1, Precision: 0.2}},
+	}, Metrics: scenariolib.Metrics{NDCG: 0.5, MRR: 1, Precision: 0.2}}
+
+	var text bytes.Buffer
+	ok(t, report.Write(&text, "text"))
+	assert(t, strings.Contains(text.String(), "ndcg@5"), "Expected the header in the text report, was %s", text.String())
+	assert(t, strings.Contains(text.String(), "0.5000"), "Expected the metrics in the text report, was %s", text.String())
+
+	var csv bytes.Buffer
+	ok(t, report.Write(&csv, "csv"))
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	equals(t, 3, len(lines))
+	equals(t, `"vpn, setup",12,0.5000,1.0000,0.2000`, lines[1])
+
+	// A JSON report can be read back.
+	path := writeTestConfig(t, "")
+	defer os.Remove(path)
+	file, err := os.Create(path)
+	ok(t, err)
+	ok(t, report.Write(file, "json"))
+	ok(t, file.Close())
+	saved, err := scenariolib.NewEvaluationReportFromPath(path)
+	ok(t, err)
+	equals(t, report, saved)
+
+	notok(t, report.Write(&text, "xml"))
+}
@@ -0,0 +1,22 @@
+package scenariolib
+
+// ============== BREADCRUMB RESET ALL EVENT ======================
+// ================================================================
+
+// BreadcrumbResetAllEvent represents the user clearing all the selected facet values
+type BreadcrumbResetAllEvent struct {
+	CustomData map[string]interface{} `json:"customData,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (reset *BreadcrumbResetAllEvent) IsValid() (bool, string) {
+	return true, ""
+}
+
+// Execute Clear the facet state, modify the AQ for the following queries in the visit
+// and sends the breadcrumbResetAll event to the analytics
+func (reset *BreadcrumbResetAllEvent) Execute(v *Visit) error {
+	Info.Println("Clearing all the facet values")
+	v.FacetState.Clear()
+	return v.sendFacetEvent("breadcrumbResetAll", "breadcrumb", reset.CustomData)
+}
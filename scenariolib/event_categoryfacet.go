@@ -0,0 +1,163 @@
+package scenariolib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+// ============== CATEGORY FACET EVENT ======================
+// ==========================================================
+
+const (
+	// CATEGORYFACETSELECT Select a path in the category facet, or descend from the current path.
+	CATEGORYFACETSELECT string = "select"
+	// CATEGORYFACETUP Go up one level in the category facet.
+	CATEGORYFACETUP string = "up"
+	// CATEGORYFACETCLEAR Clear the path of the category facet.
+	CATEGORYFACETCLEAR string = "clear"
+	// DEFAULTCATEGORYDELIMITER The delimiter between the levels of a category field when not specified.
+	DEFAULTCATEGORYDELIMITER string = "|"
+)
+
+// CategoryFacetEvent represents a navigation in a hierarchical facet, where each value of the
+// field is a full path such as Electronics|Phones.
+// Action    The navigation step: select (default), up or clear
+// Path      The path to select, from the root
+// Depth     The number of levels to descend at random from the path, using the groupBy values
+// Delimiter The delimiter between the levels in the values of the field
+type CategoryFacetEvent struct {
+	FacetTitle string                 `json:"facetTitle"`
+	FacetField string                 `json:"facetField"`
+	Action     string                 `json:"action,omitempty"`
+	Path       []string               `json:"path,omitempty"`
+	Depth      int                    `json:"depth,omitempty"`
+	Delimiter  string                 `json:"delimiter,omitempty"`
+	Pick       string                 `json:"pick,omitempty"`
+	Top        int                    `json:"top,omitempty"`
+	CustomData map[string]interface{} `json:"customData,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (category *CategoryFacetEvent) IsValid() (bool, string) {
+	if category.FacetField == "" {
+		return false, "A category facet event needs a facetField."
+	}
+	switch category.Action {
+	case "", CATEGORYFACETSELECT:
+	case CATEGORYFACETUP, CATEGORYFACETCLEAR:
+		if len(category.Path) > 0 || category.Depth > 0 {
+			return false, fmt.Sprintf("A category facet %s step cannot have a path or a depth.", category.Action)
+		}
+	default:
+		return false, fmt.Sprintf("Category facet action %s is not supported.", category.Action)
+	}
+	if category.Depth < 0 {
+		return false, "depth must be a positive integer."
+	}
+	return validFacetPick(category.Pick, category.Top)
+}
+
+// Execute Navigate the category facet, modify the AQ for the following queries in the visit
+// and sends the category facet event to the analytics
+func (category *CategoryFacetEvent) Execute(v *Visit) error {
+	if category.Delimiter == "" {
+		category.Delimiter = DEFAULTCATEGORYDELIMITER
+	}
+	path := category.currentPath(v)
+	actionCause := "categoryFacetSelect"
+
+	switch category.Action {
+	case CATEGORYFACETUP:
+		if len(path) == 0 {
+			Warning.Printf("Category facet %s is already at the root, not sending event", category.FacetField)
+			return nil
+		}
+		path = path[:len(path)-1]
+		actionCause = "categoryFacetBreadcrumb"
+	case CATEGORYFACETCLEAR:
+		path = nil
+		actionCause = "categoryFacetClear"
+	default:
+		if len(category.Path) > 0 {
+			path = append([]string{}, category.Path...)
+		}
+		depth := category.Depth
+		if len(category.Path) == 0 && depth == 0 {
+			depth = 1
+		}
+		for i := 0; i < depth; i++ {
+			next, err := category.pickChild(v, path)
+			if err != nil {
+				return err
+			}
+			path = append(path, next)
+		}
+	}
+
+	Info.Printf("Navigating category facet title=%s path=%s", category.FacetTitle, strings.Join(path, category.Delimiter))
+	v.FacetState.clearField(category.FacetField)
+	if len(path) > 0 {
+		v.FacetState.Select(category.FacetField, strings.Join(path, category.Delimiter))
+	}
+
+	if category.CustomData == nil {
+		category.CustomData = make(map[string]interface{})
+	}
+	category.CustomData["facetId"] = category.FacetField
+	category.CustomData["facetField"] = category.FacetField
+	category.CustomData["facetTitle"] = category.FacetTitle
+	category.CustomData["facetPath"] = path
+	return v.sendFacetEvent(actionCause, "categoryFacet", category.CustomData)
+}
+
+// currentPath Returns the path currently selected in the category facet of the visit.
+func (category *CategoryFacetEvent) currentPath(v *Visit) []string {
+	values := v.FacetState.Values(category.FacetField)
+	if len(values) == 0 {
+		return nil
+	}
+	return strings.Split(values[0], category.Delimiter)
+}
+
+// pickChild Request a groupBy on the children of the path and pick one of them.
+func (category *CategoryFacetEvent) pickChild(v *Visit, path []string) (string, error) {
+	prefix := ""
+	if len(path) > 0 {
+		prefix = strings.Join(path, category.Delimiter) + category.Delimiter
+	}
+	request := &search.GroupByRequest{
+		Field:                 category.FacetField,
+		MaximumNumberOfValues: DEFAULTFACETVALUES,
+		SortCriteria:          "occurrences",
+		AllowedValues:         []string{prefix + "*"},
+	}
+
+	values, err := v.facetValues(request)
+	if err != nil {
+		return "", err
+	}
+
+	// Only keep the direct children of the path, the field also holds the deeper levels.
+	children := []search.GroupByValue{}
+	for _, value := range values {
+		if !strings.HasPrefix(value.Value, prefix) {
+			continue
+		}
+		child := strings.TrimPrefix(value.Value, prefix)
+		if child == "" || strings.Contains(child, category.Delimiter) {
+			continue
+		}
+		children = append(children, search.GroupByValue{Value: child, NumberOfResults: value.NumberOfResults})
+	}
+	if len(children) == 0 {
+		return "", fmt.Errorf("No category under %s in %s", strings.Join(path, category.Delimiter), category.FacetField)
+	}
+
+	child, err := (&FacetState{}).pickFacetValue(category.FacetField, children, category.Pick, category.Top)
+	if err != nil {
+		return "", err
+	}
+	return child, nil
+}
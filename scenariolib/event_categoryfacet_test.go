@@ -0,0 +1,102 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestCategoryFacetEventValid(t *testing.T) {
+	var testEventJson = []byte(`{"facetTitle": "Category", "facetField": "@categories", "path": ["Electronics", "Phones"], "depth": 1, "customData": {"data1": "one"}}`)
+	event := &scenariolib.CategoryFacetEvent{}
+
+	// Test unmarshal json.
+	err := json.Unmarshal(testEventJson, event)
+	ok(t, err)
+
+	valid, message := event.IsValid()
+	assert(t, valid, "Expected event to be valid, was false with error: %s", message)
+
+	equals(t, []string{"Electronics", "Phones"}, event.Path)
+	equals(t, 1, event.Depth)
+
+	// Expect CustomData["data1"] to be "one"
+	equals(t, "one", event.CustomData["data1"])
+}
+
+func TestCategoryFacetEventInvalid(t *testing.T) {
+	var testEvents = [][]byte{
+		[]byte(`{"path": ["Electronics"]}`),
+		[]byte(`{"facetField": "@categories", "action": "down"}`),
+		[]byte(`{"facetField": "@categories", "action": "up", "path": ["Electronics"]}`),
+		[]byte(`{"facetField": "@categories", "depth": -1}`),
+		[]byte(`{"facetField": "@categories", "pick": "top"}`),
+	}
+
+	for _, testEventJson := range testEvents {
+		event := &scenariolib.CategoryFacetEvent{}
+		err := json.Unmarshal(testEventJson, event)
+		ok(t, err)
+
+		valid, _ := event.IsValid()
+		assert(t, !valid, "Expected event %s to be invalid.", testEventJson)
+	}
+}
+
+func TestCategoryFacetEventExecute(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// The search endpoint answers with the values of the category field, the analytics events are kept.
+	analytics := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == defaults.SEARCH_REST_PATH {
+			rw.Write([]byte(`{"groupByResults": [{"field": "categories", "values": [
+				{"value": "Electronics|Phones", "numberOfResults": 10},
+				{"value": "Electronics|Phones|Android", "numberOfResults": 6},
+				{"value": "Books|Fiction", "numberOfResults": 40}
+			]}]}`))
+			return
+		}
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		analytics = append(analytics, body)
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.LastResponse = &search.Response{}
+
+	// Descend one level from a fixed path, only the direct children are picked.
+	event := &scenariolib.CategoryFacetEvent{FacetTitle: "Category", FacetField: "@categories", Path: []string{"Electronics"}, Depth: 1}
+	ok(t, event.Execute(v))
+	equals(t, `@categories=="Electronics|Phones"`, v.LastQuery.AQ)
+	equals(t, "categoryFacetSelect", analytics[len(analytics)-1]["actionCause"])
+	equals(t, []interface{}{"Electronics", "Phones"}, analytics[len(analytics)-1]["customData"].(map[string]interface{})["facetPath"])
+
+	ok(t, (&scenariolib.CategoryFacetEvent{FacetField: "@categories", Action: "up"}).Execute(v))
+	equals(t, `@categories=="Electronics"`, v.LastQuery.AQ)
+	equals(t, "categoryFacetBreadcrumb", analytics[len(analytics)-1]["actionCause"])
+
+	ok(t, (&scenariolib.CategoryFacetEvent{FacetField: "@categories", Action: "clear"}).Execute(v))
+	equals(t, "", v.LastQuery.AQ)
+	equals(t, "categoryFacetClear", analytics[len(analytics)-1]["actionCause"])
+
+	// There is no category under Books|Fiction.
+	err = (&scenariolib.CategoryFacetEvent{FacetField: "@categories", Path: []string{"Books", "Fiction"}, Depth: 1}).Execute(v)
+	notok(t, err)
+}
@@ -23,6 +23,8 @@ type ClickEvent struct {
 	CustomData   map[string]interface{} `json:"customData,omitempty"`
 	FakeClick    bool                   `json:"fakeClick,omitempty"`
 	FakeResponse json.RawMessage        `json:"fakeResponse,omitempty"`
+	ClickModel   *ClickModel            `json:"clickModel,omitempty"`
+	Dwell        *Dwell                 `json:"dwell,omitempty"`
 }
 
 // IsValid Validate a click event by applying different validation rules of dependant parameters etc.
@@ -42,6 +44,15 @@ func (click *ClickEvent) IsValid() (bool, string) {
 	if click.FakeClick && click.FakeResponse == nil {
 		return false, "If you set parameter fakeClick to true, you must also send a fakeResponse."
 	}
+
+	if click.ClickModel != nil {
+		if valid, message := click.ClickModel.IsValid(); !valid {
+			return false, message
+		}
+	}
+	if click.Dwell != nil {
+		return click.Dwell.IsValid()
+	}
 	return true, ""
 }
 
@@ -67,24 +78,64 @@ func (click *ClickEvent) Execute(v *Visit) error {
 	}
 
 	if rand.Float64() <= click.Probability { // Probability to click
+		if click.ClickRank == -1 {
+			if model := v.clickModel(click.ClickModel); model != nil {
+				return click.executeClickModel(v, model)
+			}
+		}
 		click.ClickRank = computeClickRank(v, click.ClickRank, click.Offset)
 
 		// We leave this option because it means voluntarily someone set a clickRank > number of results for his query.
-		if click.ClickRank > v.LastResponse.TotalCount {
+		if click.ClickRank >= len(v.LastResponse.Results) {
 			Warning.Printf("PageView index out of bounds, not sending event")
 			return nil
 		}
 
-		err := v.sendClickEvent(click.ClickRank, click.Quickview, click.CustomData)
-		if err != nil {
-			return err
+		// Only the click model of the event decides whether an explicit rank is examined.
+		if click.ClickModel != nil && rand.Float64() >= click.ClickModel.ExaminationProbability(click.ClickRank) {
+			Info.Printf("User did not examine the result at rank %d", click.ClickRank+1)
+			return nil
 		}
-		return nil
+
+		return click.send(v, click.ClickRank)
 	}
 	Info.Printf("User chose not to click (probability %v%%)", int(click.Probability*100))
 	return nil
 }
 
+// executeClickModel Send the clicks of the user simulated with the click model on the page of results.
+func (click *ClickEvent) executeClickModel(v *Visit, model *ClickModel) error {
+	ranks := model.ClickRanks(len(v.LastResponse.Results))
+	if len(ranks) == 0 {
+		Info.Printf("User did not find a result to click with the %s click model", model.Type)
+		return nil
+	}
+	for i, rank := range ranks {
+		if i > 0 && v.WaitBetweenActions {
+			timeToWait := v.Config.TimeBetweenActions
+			if timeToWait <= 0 {
+				timeToWait = DEFAULTTIMEBETWEENACTIONS
+			}
+			WaitBetweenActions(timeToWait, v.Config.IsWaitConstant)
+		}
+		if err := click.send(v, rank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send Send the click on the result at the rank, then simulate the dwell on the document.
+func (click *ClickEvent) send(v *Visit, rank int) error {
+	if err := v.sendClickEvent(rank, click.Quickview, click.CustomData); err != nil {
+		return err
+	}
+	if dwell := v.dwell(click.Dwell); dwell != nil {
+		return v.dwellOn(dwell, rank, click.Quickview, click.CustomData, 0)
+	}
+	return nil
+}
+
 // Randomize a click rank if the clickRank is -1
 func computeClickRank(v *Visit, clickRank, offset int) (computedRank int) {
 	computedRank = clickRank
@@ -93,7 +144,7 @@ func computeClickRank(v *Visit, clickRank, offset int) (computedRank int) {
 		computedRank = 0
 		// Find a random rank within the possible click values accounting for the offset
 		if v.LastResponse.TotalCount > 1 {
-			topL := Min(v.LastQuery.NumberOfResults, v.LastResponse.TotalCount)
+			topL := Min(v.LastQuery.NumberOfResults, v.LastResponse.TotalCount-v.LastQuery.FirstResult)
 			rndRank := int(math.Abs(rand.NormFloat64()*2)) + offset
 			computedRank = Min(rndRank, topL-1)
 		}
@@ -1,48 +1,112 @@
 package scenariolib
 
 import (
-	"fmt"
+	"github.com/coveooss/go-coveo/search"
 )
 
 // ============== FACET CHANGE EVENT ======================
 // ======================================================
 
-// FacetEvent represents a tab change event
+// FacetEvent represents the selection of a value in a facet. Without a facetValue, the value is
+// picked from the groupBy results of the facetField.
+// Pick                    How the value is picked: weighted (default), random or top
+// Top                     The number of values with the most results to pick from with top
+// MaximumNumberOfValues   The number of values requested in the groupBy
+// RangeValues             The ranges of a range facet (numeric or date buckets)
+// GenerateAutomaticRanges Let the index generate the ranges of a range facet
 type FacetEvent struct {
-	FacetTitle string                 `json:"facetTitle"`
-	FacetValue string                 `json:"facetValue"`
-	FacetField string                 `json:"facetField"`
-	CustomData map[string]interface{} `json:"customData,omitempty"`
+	FacetTitle              string                 `json:"facetTitle"`
+	FacetValue              string                 `json:"facetValue"`
+	FacetField              string                 `json:"facetField"`
+	Pick                    string                 `json:"pick,omitempty"`
+	Top                     int                    `json:"top,omitempty"`
+	MaximumNumberOfValues   int                    `json:"maximumNumberOfValues,omitempty"`
+	RangeValues             []*search.RangeValue   `json:"rangeValues,omitempty"`
+	GenerateAutomaticRanges bool                   `json:"generateAutomaticRanges,omitempty"`
+	CustomData              map[string]interface{} `json:"customData,omitempty"`
 }
 
 // IsValid Additional validation after the json unmarshal.
 func (facet *FacetEvent) IsValid() (bool, string) {
+	if facet.FacetField == "" {
+		return false, "A facet event needs a facetField."
+	}
+	if facet.FacetValue != "" && facet.Pick != "" {
+		return false, "A facet event cannot have both a facetValue and a pick."
+	}
+	if valid, message := validFacetPick(facet.Pick, facet.Top); !valid {
+		return false, message
+	}
+	if facet.MaximumNumberOfValues < 0 {
+		return false, "maximumNumberOfValues must be a positive integer."
+	}
 	return true, ""
 }
 
-// Execute Sends the tabchange event to the analytics and modify the CQ for the
-// following queries in the visit
+// Execute Add the value to the selected values of the facet, modify the AQ for the
+// following queries in the visit and sends the facetSelect event to the analytics
 func (facet *FacetEvent) Execute(v *Visit) error {
+	if facet.FacetValue == "" {
+		if err := facet.pickValue(v); err != nil {
+			return err
+		}
+	}
+
 	Info.Printf("Clicking on facet title=%s value=%s", facet.FacetTitle, facet.FacetValue)
+	var selected bool
+	if facet.isRange() {
+		selected = v.FacetState.SelectRange(facet.FacetField, facet.FacetValue)
+	} else {
+		selected = v.FacetState.Select(facet.FacetField, facet.FacetValue)
+	}
+	if !selected {
+		Warning.Printf("Facet value %s is already selected in %s", facet.FacetValue, facet.FacetField)
+	}
+
+	Info.Printf("Sending FacetChange Event title=%s value=%s", facet.FacetTitle, facet.FacetValue)
+	return v.sendFacetEvent("facetSelect", "facet", facet.customData())
+}
+
+// isRange Returns true if the facet values are ranges.
+func (facet *FacetEvent) isRange() bool {
+	return facet.GenerateAutomaticRanges || len(facet.RangeValues) > 0
+}
 
-	v.LastQuery.AQ = fmt.Sprintf("%s==\"%s\"", facet.FacetField, facet.FacetValue)
+// pickValue Request a groupBy on the facet field and pick the facet value from its values.
+func (facet *FacetEvent) pickValue(v *Visit) error {
+	request := &search.GroupByRequest{
+		Field:                   facet.FacetField,
+		MaximumNumberOfValues:   facet.MaximumNumberOfValues,
+		GenerateAutomaticRanges: facet.GenerateAutomaticRanges,
+		RangeValues:             facet.RangeValues,
+	}
+	if request.MaximumNumberOfValues == 0 {
+		request.MaximumNumberOfValues = DEFAULTFACETVALUES
+	}
+	if !facet.isRange() {
+		request.SortCriteria = "occurrences"
+	}
 
-	resp, err := v.SearchClient.Query(*v.LastQuery)
+	values, err := v.facetValues(request)
 	if err != nil {
 		return err
 	}
-	v.LastResponse = resp
+	value, err := v.FacetState.pickFacetValue(facet.FacetField, values, facet.Pick, facet.Top)
+	if err != nil {
+		return err
+	}
+	facet.FacetValue = value
+	return nil
+}
 
-	Info.Printf("Sending FacetChange Event title=%s value=%s", facet.FacetTitle, facet.FacetValue)
+// customData Returns the customData of the facet analytics events.
+func (facet *FacetEvent) customData() map[string]interface{} {
 	if facet.CustomData == nil {
 		facet.CustomData = make(map[string]interface{})
 	}
 	facet.CustomData["facetValue"] = facet.FacetValue
 	facet.CustomData["facetTitle"] = facet.FacetTitle
 	facet.CustomData["facetId"] = facet.FacetField
-	err = v.sendInterfaceChangeEvent("facetSelect", "facet", facet.CustomData)
-	if err != nil {
-		return err
-	}
-	return nil
+	facet.CustomData["facetField"] = facet.FacetField
+	return facet.CustomData
 }
@@ -2,9 +2,14 @@ package scenariolib_test
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/coveo/uabot/defaults"
 	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
 )
 
 func TestFacetEventValid(t *testing.T) {
@@ -30,3 +35,150 @@ func TestFacetEventValid(t *testing.T) {
 	// Expect CustomData["data1"] to be "one"
 	equals(t, "one", event.CustomData["data1"])
 }
+
+func TestFacetEventInvalid(t *testing.T) {
+	var testEvents = [][]byte{
+		[]byte(`{"facetTitle": "Type", "facetValue": "Message"}`),
+		[]byte(`{"facetField": "@objecttype", "facetValue": "Message", "pick": "random"}`),
+		[]byte(`{"facetField": "@objecttype", "pick": "top"}`),
+		[]byte(`{"facetField": "@objecttype", "pick": "first"}`),
+		[]byte(`{"facetField": "@objecttype", "maximumNumberOfValues": -1}`),
+	}
+
+	for _, testEventJson := range testEvents {
+		event := &scenariolib.FacetEvent{}
+		err := json.Unmarshal(testEventJson, event)
+		ok(t, err)
+
+		valid, _ := event.IsValid()
+		assert(t, !valid, "Expected event %s to be invalid.", testEventJson)
+	}
+}
+
+func TestFacetEventsExecute(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// All requests caught by the Test server will be added to 'requests'
+	requests := make(map[string]RestRequest)
+
+	server := createTestServer(t, requests)
+	defer server.Close() // Close the server when test finishes
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.LastQuery.AQ = `$some(keywords: "case subject")`
+
+	lastActionCause := func() string {
+		req, exists := requests["/rest/v15/analytics/search/"]
+		assert(t, exists, "Missing request for /rest/v15/analytics/search/")
+		body := map[string]interface{}{}
+		ok(t, json.Unmarshal(req.Body, &body))
+		return body["actionCause"].(string)
+	}
+
+	ok(t, (&scenariolib.FacetEvent{FacetTitle: "Type", FacetField: "@objecttype", FacetValue: "Message"}).Execute(v))
+	ok(t, (&scenariolib.FacetEvent{FacetTitle: "Type", FacetField: "@objecttype", FacetValue: "Case"}).Execute(v))
+	ok(t, (&scenariolib.FacetEvent{FacetTitle: "Source", FacetField: "@source", FacetValue: "Forum"}).Execute(v))
+	equals(t, "facetSelect", lastActionCause())
+	equals(t, `$some(keywords: "case subject") @objecttype==("Message","Case") @source=="Forum"`, v.LastQuery.AQ)
+
+	req, exists := requests["/rest/search/"]
+	assert(t, exists, "Missing request for /rest/search/")
+	query := &search.Query{}
+	ok(t, json.Unmarshal(req.Body, query))
+	equals(t, v.LastQuery.AQ, query.AQ)
+
+	deselect := &scenariolib.FacetDeselectEvent{}
+	ok(t, json.Unmarshal([]byte(`{"facetTitle": "Type", "facetField": "@objecttype", "facetValue": "Message"}`), deselect))
+	ok(t, deselect.Execute(v))
+	equals(t, "facetDeselect", lastActionCause())
+	equals(t, `$some(keywords: "case subject") @objecttype=="Case" @source=="Forum"`, v.LastQuery.AQ)
+
+	ok(t, (&scenariolib.BreadcrumbResetAllEvent{}).Execute(v))
+	equals(t, "breadcrumbResetAll", lastActionCause())
+	equals(t, `$some(keywords: "case subject")`, v.LastQuery.AQ)
+}
+
+func TestFacetEventPickValue(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// The index returns the range of @size, keep the queries and the facet events sent.
+	queries := []*search.Query{}
+	facetEvents := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case defaults.SEARCH_REST_PATH:
+			query := &search.Query{}
+			json.NewDecoder(req.Body).Decode(query)
+			queries = append(queries, query)
+			rw.Write([]byte(`{"totalCount": 60, "results": [{"uri": "uri", "raw": {"urihash": "hash"}}], "groupByResults": [{"field": "size", "values": [{"value": "0..100", "numberOfResults": 60}]}]}`))
+			return
+		case defaults.ANALYTICS_REST_PATH + "search/":
+			body := map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&body)
+			facetEvents = append(facetEvents, body)
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	groupByResponse := func() *search.Response {
+		return &search.Response{GroupByResults: []search.GroupByResult{{
+			Field: "objecttype",
+			Values: []search.GroupByValue{
+				{Value: "Message", NumberOfResults: 10},
+				{Value: "Case", NumberOfResults: 50},
+				{Value: "Document", NumberOfResults: 0},
+			},
+		}}}
+	}
+	v.LastQuery.GroupByRequests = []*search.GroupByRequest{{Field: "@objecttype"}}
+
+	v.LastResponse = groupByResponse()
+	event := &scenariolib.FacetEvent{FacetField: "@objecttype", Pick: "top", Top: 1}
+	ok(t, event.Execute(v))
+	equals(t, "Case", event.FacetValue)
+
+	// Values already selected and values without results are never picked.
+	v.LastResponse = groupByResponse()
+	event = &scenariolib.FacetEvent{FacetField: "@objecttype"}
+	ok(t, event.Execute(v))
+	equals(t, "Message", event.FacetValue)
+	equals(t, `@objecttype==("Case","Message")`, v.LastQuery.AQ)
+
+	// Range facets request their ranges in a groupBy, the range is selected without quotes.
+	queries = queries[:0]
+	facetEvents = facetEvents[:0]
+	event = &scenariolib.FacetEvent{FacetField: "@size", RangeValues: []*search.RangeValue{{From: 0, To: 100}}}
+	ok(t, event.Execute(v))
+	equals(t, "0..100", event.FacetValue)
+	equals(t, `@objecttype==("Case","Message") @size==0..100`, v.LastQuery.AQ)
+
+	equals(t, 2, len(queries))
+	equals(t, 2, len(queries[0].GroupByRequests))
+	equals(t, "@size", queries[0].GroupByRequests[1].Field)
+	equals(t, 1, len(queries[0].GroupByRequests[1].RangeValues))
+	equals(t, v.LastQuery.AQ, queries[1].AQ)
+
+	equals(t, 1, len(facetEvents))
+	equals(t, "facetSelect", facetEvents[0]["actionCause"])
+	equals(t, "0..100", facetEvents[0]["customData"].(map[string]interface{})["facetValue"])
+}
@@ -0,0 +1,34 @@
+package scenariolib
+
+import (
+	"math/rand"
+)
+
+// ============== FACET DESELECT EVENT ======================
+// ==========================================================
+
+// FacetDeselectEvent represents the user removing a selected value of a facet
+type FacetDeselectEvent struct {
+	FacetEvent
+}
+
+// Execute Remove the value from the selected values of the facet, modify the AQ for the
+// following queries in the visit and sends the facetDeselect event to the analytics.
+// Without a facetValue, one of the selected values of the facet is removed at random.
+func (facet *FacetDeselectEvent) Execute(v *Visit) error {
+	if facet.FacetValue == "" {
+		selected := v.FacetState.Values(facet.FacetField)
+		if len(selected) == 0 {
+			Warning.Printf("No value is selected in %s, not sending event", facet.FacetField)
+			return nil
+		}
+		facet.FacetValue = selected[rand.Intn(len(selected))]
+	}
+
+	Info.Printf("Deselecting facet title=%s value=%s", facet.FacetTitle, facet.FacetValue)
+	if !v.FacetState.Deselect(facet.FacetField, facet.FacetValue) {
+		Warning.Printf("Facet value %s is not selected in %s, not sending event", facet.FacetValue, facet.FacetField)
+		return nil
+	}
+	return v.sendFacetEvent("facetDeselect", "facet", facet.customData())
+}
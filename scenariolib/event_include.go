@@ -0,0 +1,41 @@
+package scenariolib
+
+import (
+	"fmt"
+)
+
+// ============== INCLUDE EVENT ======================
+// ===================================================
+
+// INCLUDEEVENTTYPE The type of the event including a block of events from the config.
+const INCLUDEEVENTTYPE string = "Include"
+
+// IncludeEvent A reference to a named block of events declared in the config eventBlocks.
+// Include events of linear scenarios are replaced by the events of the block when the config is loaded.
+type IncludeEvent struct {
+	Block string `json:"block"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (include *IncludeEvent) IsValid() (bool, string) {
+	if include.Block == "" {
+		return false, "An Include event needs the name of a block."
+	}
+	return true, ""
+}
+
+// Execute the events of the block one after the other, used when the include could not be
+// replaced when loading the config, as the event of a markov state.
+func (include *IncludeEvent) Execute(v *Visit) error {
+	events, ok := v.Config.EventBlocks[include.Block]
+	if !ok {
+		return fmt.Errorf("Event block %s is not defined", include.Block)
+	}
+	Info.Printf("Executing event block %s", include.Block)
+	for i := range events {
+		if err := v.executeEvent(&events[i], v.Config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
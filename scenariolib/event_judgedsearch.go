@@ -0,0 +1,69 @@
+package scenariolib
+
+import (
+	"errors"
+)
+
+// ============== JUDGED SEARCH EVENT ======================
+// =========================================================
+
+// JudgedSearchEvent a search for a judged query, followed by clicks on the results
+// depending on their rank and on the relevance judgments of their document.
+type JudgedSearchEvent struct {
+	ActionCause string                 `json:"actionCause,omitempty"`
+	Quickview   bool                   `json:"quickview,omitempty"`
+	CustomData  map[string]interface{} `json:"customData,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+	ClickModel  *ClickModel            `json:"clickModel,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (judged *JudgedSearchEvent) IsValid() (bool, string) {
+	if judged.ClickModel != nil {
+		return judged.ClickModel.IsValid()
+	}
+	return true, ""
+}
+
+// Execute Search for a random judged query, then simulate the clicks of the user with the
+// click model, a result attracting the user according to the grade of its document.
+func (judged *JudgedSearchEvent) Execute(v *Visit) error {
+	judgments := v.Config.Judgments
+	if judgments == nil {
+		return errors.New("A JudgedSearch needs judgments in the config")
+	}
+
+	candidates := append(append([]string{}, v.Config.GoodQueries...), v.Config.HarvestedGoodQueries...)
+	query, err := randomQuery(judgments.queries(candidates), v.Config.QueryPopularity)
+	if err != nil {
+		return errors.New("No judged query to search")
+	}
+
+	search := &SearchEvent{Query: query, ActionCause: judged.ActionCause, CustomData: judged.CustomData, Context: judged.Context}
+	if err = search.Execute(v); err != nil {
+		return err
+	}
+	if v.LastResponse.TotalCount < 1 {
+		Warning.Printf("Judged query %s returned no results", query)
+		return nil
+	}
+
+	// The grades replace the attractiveness of the click model, the position bias stays.
+	model := &ClickModel{Type: CLICKMODELPOSITION}
+	if eventModel := v.clickModel(judged.ClickModel); eventModel != nil {
+		copied := *eventModel
+		model = &copied
+	}
+	model.Attractiveness = judgments.ClickProbabilities(query, v.LastResponse.Results)
+
+	if v.WaitBetweenActions {
+		timeToWait := v.Config.TimeBetweenActions
+		if timeToWait <= 0 {
+			timeToWait = DEFAULTTIMEBETWEENACTIONS
+		}
+		WaitBetweenActions(timeToWait, v.Config.IsWaitConstant)
+	}
+
+	click := &ClickEvent{Quickview: judged.Quickview, CustomData: judged.CustomData}
+	return click.executeClickModel(v, model)
+}
@@ -0,0 +1,161 @@
+package scenariolib
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+// ============== OMNIBOX SEARCH EVENT ======================
+// ==========================================================
+
+const (
+	// DEFAULTKEYSTROKEDELAY The average time in milliseconds between two keystrokes when not specified.
+	DEFAULTKEYSTROKEDELAY int = 200
+	// DEFAULTSUGGESTIONPROBABILITY The probability that the user picks a suggestion when not specified.
+	DEFAULTSUGGESTIONPROBABILITY float64 = 0.5
+	// DEFAULTNUMBEROFSUGGESTIONS The number of query suggestions requested at each keystroke when not specified.
+	DEFAULTNUMBEROFSUGGESTIONS int = 5
+)
+
+// OmniboxSearchEvent represents a user typing a query in the omnibox, character by character,
+// with query suggestions requested at each keystroke.
+// SuggestionProbability The probability that the user picks a suggestion instead of submitting the query
+// MinChars              The number of characters typed before suggestions are requested
+// KeystrokeDelay        The average time in milliseconds between two keystrokes
+// NumberOfSuggestions   The number of suggestions requested at each keystroke
+type OmniboxSearchEvent struct {
+	Query                 string                 `json:"queryText,omitempty"`
+	GoodQuery             bool                   `json:"goodQuery,omitempty"`
+	MatchLanguage         bool                   `json:"matchLanguage,omitempty"`
+	SuggestionProbability *float64               `json:"suggestionProbability,omitempty"`
+	MinChars              int                    `json:"minChars,omitempty"`
+	KeystrokeDelay        int                    `json:"keystrokeDelay,omitempty"`
+	NumberOfSuggestions   int                    `json:"numberOfSuggestions,omitempty"`
+	CustomData            map[string]interface{} `json:"customData,omitempty"`
+	Context               map[string]interface{} `json:"context,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (omnibox *OmniboxSearchEvent) IsValid() (bool, string) {
+	if omnibox.SuggestionProbability != nil && (*omnibox.SuggestionProbability < 0 || *omnibox.SuggestionProbability > 1) {
+		return false, "suggestionProbability must be between 0 and 1."
+	}
+	if omnibox.MinChars < 0 || omnibox.KeystrokeDelay < 0 || omnibox.NumberOfSuggestions < 0 {
+		return false, "minChars, keystrokeDelay and numberOfSuggestions must be positive integers."
+	}
+	return true, ""
+}
+
+// Execute Type the query one character at a time while requesting query suggestions, then
+// search for a suggestion (omniboxAnalytics) or for the query typed (searchboxSubmit).
+func (omnibox *OmniboxSearchEvent) Execute(v *Visit) error {
+	searchEvent := &SearchEvent{
+		Query:         omnibox.Query,
+		GoodQuery:     omnibox.GoodQuery,
+		MatchLanguage: omnibox.MatchLanguage,
+		CustomData:    omnibox.CustomData,
+		Context:       omnibox.Context,
+	}
+	if searchEvent.Query == "" { // if the query is empty, randomize one
+		queriesToRandom, err := searchEvent.getQueriesToRandomize(v)
+		if err != nil {
+			return err
+		}
+		if searchEvent.Query, err = randomQuery(queriesToRandom, v.Config.QueryPopularity); err != nil {
+			return err
+		}
+	}
+
+	probability := DEFAULTSUGGESTIONPROBABILITY
+	if omnibox.SuggestionProbability != nil {
+		probability = *omnibox.SuggestionProbability
+	}
+	pickSuggestion := rand.Float64() < probability
+
+	typed := []rune(searchEvent.Query)
+	partialQueries := []string{}
+	var completions []search.Completion
+	for i := 1; i <= len(typed); i++ {
+		omnibox.waitKeystroke(v)
+		partialQuery := string(typed[:i])
+		if i < omnibox.MinChars {
+			continue
+		}
+		partialQueries = append(partialQueries, partialQuery)
+
+		var err error
+		if completions, err = omnibox.suggest(v, partialQuery); err != nil {
+			return err
+		}
+		if !pickSuggestion {
+			continue
+		}
+		for rank, completion := range completions {
+			if strings.EqualFold(completion.Expression, searchEvent.Query) {
+				return omnibox.selectSuggestion(v, searchEvent, completion.Expression, rank, partialQueries)
+			}
+		}
+	}
+
+	// The query the user had in mind was never suggested, pick another suggestion.
+	if pickSuggestion && len(completions) > 0 {
+		rank := rand.Intn(len(completions))
+		return omnibox.selectSuggestion(v, searchEvent, completions[rank].Expression, rank, partialQueries)
+	}
+
+	Info.Printf("Submitting omnibox query : %s", searchEvent.Query)
+	return searchEvent.Execute(v)
+}
+
+// waitKeystroke Wait between two keystrokes, from half to one and a half the keystroke delay.
+func (omnibox *OmniboxSearchEvent) waitKeystroke(v *Visit) {
+	if !v.WaitBetweenActions {
+		return
+	}
+	delay := omnibox.KeystrokeDelay
+	if delay == 0 {
+		delay = DEFAULTKEYSTROKEDELAY
+	}
+	delay = delay/2 + rand.Intn(delay+1)
+	time.Sleep(time.Duration(delay) * time.Millisecond)
+}
+
+// suggest Request the query suggestions of a partial query.
+func (omnibox *OmniboxSearchEvent) suggest(v *Visit, partialQuery string) ([]search.Completion, error) {
+	count := omnibox.NumberOfSuggestions
+	if count == 0 {
+		count = DEFAULTNUMBEROFSUGGESTIONS
+	}
+	resp, err := v.SearchClient.QuerySuggest(search.QuerySuggestRequest{
+		Q:         partialQuery,
+		Count:     count,
+		Language:  v.Language,
+		SearchHub: v.LastQuery.SearchHub,
+		Pipeline:  v.LastQuery.Pipeline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("Empty query suggest response")
+	}
+	return resp.Completions, nil
+}
+
+// selectSuggestion Search for the suggestion picked in the omnibox and send the omniboxAnalytics event.
+func (omnibox *OmniboxSearchEvent) selectSuggestion(v *Visit, searchEvent *SearchEvent, suggestion string, rank int, partialQueries []string) error {
+	Info.Printf("Selecting suggestion %s at rank %d", suggestion, rank)
+	if searchEvent.CustomData == nil {
+		searchEvent.CustomData = make(map[string]interface{})
+	}
+	searchEvent.CustomData["partialQuery"] = partialQueries[len(partialQueries)-1]
+	searchEvent.CustomData["suggestionRanking"] = rank
+	searchEvent.CustomData["partialQueries"] = strings.Join(partialQueries, ";")
+	searchEvent.Query = suggestion
+	searchEvent.ActionCause = "omniboxAnalytics"
+	return searchEvent.Execute(v)
+}
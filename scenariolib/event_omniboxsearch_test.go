@@ -0,0 +1,105 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestOmniboxSearchEventValid(t *testing.T) {
+	var testEventJson = []byte(`{"queryText": "coveo", "suggestionProbability": 0.8, "minChars": 2, "keystrokeDelay": 100, "customData": {"data1": "one"}}`)
+	event := &scenariolib.OmniboxSearchEvent{}
+
+	// Test unmarshal json.
+	err := json.Unmarshal(testEventJson, event)
+	ok(t, err)
+
+	valid, message := event.IsValid()
+	assert(t, valid, "Expected event to be valid, was false with error: %s", message)
+
+	equals(t, "coveo", event.Query)
+	equals(t, 0.8, *event.SuggestionProbability)
+	equals(t, 2, event.MinChars)
+
+	// Expect CustomData["data1"] to be "one"
+	equals(t, "one", event.CustomData["data1"])
+}
+
+func TestOmniboxSearchEventInvalid(t *testing.T) {
+	var testEvents = [][]byte{
+		[]byte(`{"suggestionProbability": 1.5}`),
+		[]byte(`{"minChars": -1}`),
+		[]byte(`{"keystrokeDelay": -100}`),
+	}
+
+	for _, testEventJson := range testEvents {
+		event := &scenariolib.OmniboxSearchEvent{}
+		err := json.Unmarshal(testEventJson, event)
+		ok(t, err)
+
+		valid, _ := event.IsValid()
+		assert(t, !valid, "Expected event %s to be invalid.", testEventJson)
+	}
+}
+
+func TestOmniboxSearchEventExecute(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// The query suggest endpoint suggests "coveo" at rank 1 once "cov" is typed.
+	partialQueries := []string{}
+	var lastSearchEvent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch {
+		case strings.HasSuffix(req.URL.Path, "querySuggest"):
+			partialQueries = append(partialQueries, body["q"].(string))
+			if len(body["q"].(string)) >= 3 {
+				rw.Write([]byte(`{"completions": [{"expression": "coveo cloud"}, {"expression": "Coveo"}]}`))
+				return
+			}
+			rw.Write([]byte(`{"completions": []}`))
+			return
+		case strings.HasSuffix(req.URL.Path, "search/") && req.URL.Path != defaults.SEARCH_REST_PATH:
+			lastSearchEvent = body
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.WaitBetweenActions = false
+
+	always := 1.0
+	event := &scenariolib.OmniboxSearchEvent{Query: "coveo", SuggestionProbability: &always, MinChars: 2}
+	ok(t, event.Execute(v))
+	equals(t, []string{"co", "cov"}, partialQueries)
+	equals(t, "Coveo", v.LastQuery.Q)
+	equals(t, "omniboxAnalytics", lastSearchEvent["actionCause"])
+	customData := lastSearchEvent["customData"].(map[string]interface{})
+	equals(t, "cov", customData["partialQuery"])
+	equals(t, 1.0, customData["suggestionRanking"])
+	equals(t, "co;cov", customData["partialQueries"])
+
+	partialQueries = []string{}
+	never := 0.0
+	event = &scenariolib.OmniboxSearchEvent{Query: "coveo", SuggestionProbability: &never}
+	ok(t, event.Execute(v))
+	equals(t, []string{"c", "co", "cov", "cove", "coveo"}, partialQueries)
+	equals(t, "coveo", v.LastQuery.Q)
+	equals(t, "searchboxSubmit", lastSearchEvent["actionCause"])
+}
@@ -0,0 +1,81 @@
+package scenariolib
+
+import (
+	"errors"
+)
+
+// ============== PAGINATE EVENT ======================
+// ====================================================
+
+const (
+	// PAGINATENEXT Go to the next page of results.
+	PAGINATENEXT string = "next"
+	// PAGINATEPREVIOUS Go to the previous page of results.
+	PAGINATEPREVIOUS string = "previous"
+)
+
+// PaginateEvent represents the user changing the page of results, either to the next
+// or previous page or to a specific page number (1 based).
+type PaginateEvent struct {
+	Direction  string                 `json:"direction,omitempty"`
+	Page       int                    `json:"page,omitempty"`
+	CustomData map[string]interface{} `json:"customData,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (paginate *PaginateEvent) IsValid() (bool, string) {
+	if paginate.Direction == "" && paginate.Page == 0 {
+		return false, "A Paginate event needs either a direction (next, previous) or a page."
+	}
+	if paginate.Direction != "" && paginate.Page != 0 {
+		return false, "A Paginate event cannot have both a direction and a page."
+	}
+	if paginate.Direction != "" && paginate.Direction != PAGINATENEXT && paginate.Direction != PAGINATEPREVIOUS {
+		return false, "A Paginate event direction must be next or previous."
+	}
+	if paginate.Page < 0 {
+		return false, "A Paginate event page must be a positive integer."
+	}
+	return true, ""
+}
+
+// Execute Change the first result of the query to the requested page, run the query and
+// send the pager event to the analytics.
+func (paginate *PaginateEvent) Execute(v *Visit) error {
+	if v.LastResponse == nil {
+		return errors.New("No query before Paginate event, use a search event first")
+	}
+	pageSize := v.LastQuery.NumberOfResults
+	if pageSize < 1 {
+		pageSize = DEFAULTNUMBEROFRESULTS
+	}
+	currentPage := v.LastQuery.FirstResult/pageSize + 1
+
+	page, actionCause := paginate.Page, "pagerNumber"
+	switch paginate.Direction {
+	case PAGINATENEXT:
+		page, actionCause = currentPage+1, "pagerNext"
+	case PAGINATEPREVIOUS:
+		page, actionCause = currentPage-1, "pagerPrevious"
+	}
+
+	if page < 1 || (page-1)*pageSize >= v.LastResponse.TotalCount {
+		Warning.Printf("Page %d is out of the %d results of the last query, not changing page", page, v.LastResponse.TotalCount)
+		return nil
+	}
+
+	Info.Printf("Going to page %d", page)
+	v.LastQuery.FirstResult = (page - 1) * pageSize
+
+	resp, err := v.SearchClient.Query(*v.LastQuery)
+	if err != nil {
+		return err
+	}
+	v.LastResponse = resp
+
+	if paginate.CustomData == nil {
+		paginate.CustomData = make(map[string]interface{})
+	}
+	paginate.CustomData["pagerNumber"] = page
+	return v.sendInterfaceChangeEvent(actionCause, "getMoreResults", paginate.CustomData)
+}
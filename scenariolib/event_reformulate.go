@@ -0,0 +1,217 @@
+package scenariolib
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// ============== REFORMULATE EVENT ======================
+// =======================================================
+
+const (
+	// REFORMULATEADD Add a term of the pool to the query.
+	REFORMULATEADD string = "add"
+	// REFORMULATEREMOVE Remove a term from the query.
+	REFORMULATEREMOVE string = "remove"
+	// REFORMULATESYNONYM Replace a term of the query with one of its synonyms.
+	REFORMULATESYNONYM string = "synonym"
+	// REFORMULATEFIXTYPO Replace the query with the correction of the index.
+	REFORMULATEFIXTYPO string = "fixTypo"
+)
+
+// ReformulateEvent represents a user rewriting the last query, one or many times.
+// Strategies   The ways to derive the next query, one that applies is picked at random each time
+// Terms        The pool of terms added to the query
+// Synonyms     The synonyms of the terms of the query
+// Times        The maximum number of reformulations
+// MatchField   With MatchPattern, the user stops once a result matches the pattern
+// ActionCause  The action cause of the add, remove and synonym reformulations, fixTypo being a
+// click on the Did You Mean correction
+type ReformulateEvent struct {
+	Strategies   []string               `json:"strategies,omitempty"`
+	Terms        []string               `json:"terms,omitempty"`
+	Synonyms     map[string][]string    `json:"synonyms,omitempty"`
+	Times        int                    `json:"times,omitempty"`
+	MatchField   string                 `json:"matchField,omitempty"`
+	MatchPattern string                 `json:"matchPattern,omitempty"`
+	ActionCause  string                 `json:"actionCause,omitempty"`
+	CustomData   map[string]interface{} `json:"customData,omitempty"`
+	RegexMatch   *regexp.Regexp
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (reformulate *ReformulateEvent) IsValid() (bool, string) {
+	for _, strategy := range reformulate.Strategies {
+		switch strategy {
+		case REFORMULATEADD, REFORMULATEREMOVE, REFORMULATESYNONYM, REFORMULATEFIXTYPO:
+		default:
+			return false, fmt.Sprintf("Reformulation strategy %s is not supported.", strategy)
+		}
+	}
+	if reformulate.Times < 0 {
+		return false, "times must be a positive integer."
+	}
+	if (reformulate.MatchField == "") != (reformulate.MatchPattern == "") {
+		return false, "You must provide both matchField and matchPattern, or none of them."
+	}
+	if reformulate.MatchPattern != "" {
+		var err error
+		if reformulate.RegexMatch, err = regexp.Compile(reformulate.MatchPattern); err != nil {
+			return false, fmt.Sprintf("matchPattern is not a valid regular expression : %v", err)
+		}
+	}
+	return true, ""
+}
+
+// Execute Search for queries derived from the last query until a result matches the pattern,
+// sending a search event for each of them.
+func (reformulate *ReformulateEvent) Execute(v *Visit) error {
+	times := reformulate.Times
+	if times == 0 {
+		times = 1
+	}
+
+	// fixTypo follows the corrections of the index, so the searches ask for them.
+	if reformulate.fixesTypos() && v.LastResponse != nil {
+		enableDidYouMean := v.LastQuery.EnableDidYouMean
+		defer func() { v.LastQuery.EnableDidYouMean = enableDidYouMean }()
+		v.LastQuery.EnableDidYouMean = true
+		if !enableDidYouMean && len(v.LastResponse.QueryCorrections) == 0 {
+			resp, err := v.SearchClient.Query(*v.LastQuery)
+			if err != nil {
+				return err
+			}
+			v.LastResponse.QueryCorrections = resp.QueryCorrections
+		}
+	}
+
+	for i := 0; i < times; i++ {
+		if reformulate.RegexMatch != nil && v.FindDocumentRankByMatchingField(reformulate.MatchField, reformulate.RegexMatch) >= 0 {
+			Info.Printf("A result matches %s, no more reformulation", reformulate.MatchPattern)
+			return nil
+		}
+
+		query, strategy, ok := reformulate.nextQuery(v)
+		if !ok {
+			Warning.Printf("Cannot reformulate the query %s", v.LastQuery.Q)
+			return nil
+		}
+		Info.Printf("Reformulating %s into %s (%s)", v.LastQuery.Q, query, strategy)
+
+		searchEvent := &SearchEvent{Query: query, ActionCause: reformulate.ActionCause, CustomData: reformulate.CustomData}
+		if strategy == REFORMULATEFIXTYPO {
+			searchEvent.ActionCause = didYouMeanClickCause
+			searchEvent.CustomData = correctionCustomData(reformulate.CustomData, v.LastQuery.Q)
+		}
+		if err := searchEvent.Execute(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixesTypos Returns true if the fixTypo strategy can be used.
+func (reformulate *ReformulateEvent) fixesTypos() bool {
+	if len(reformulate.Strategies) == 0 {
+		return true
+	}
+	for _, strategy := range reformulate.Strategies {
+		if strategy == REFORMULATEFIXTYPO {
+			return true
+		}
+	}
+	return false
+}
+
+// nextQuery Derive the next query from the last query with one of the strategies that applies,
+// returns the query and the strategy used.
+func (reformulate *ReformulateEvent) nextQuery(v *Visit) (string, string, bool) {
+	strategies := reformulate.Strategies
+	if len(strategies) == 0 {
+		strategies = []string{REFORMULATEADD, REFORMULATEREMOVE, REFORMULATESYNONYM, REFORMULATEFIXTYPO}
+	}
+
+	terms := strings.Fields(v.LastQuery.Q)
+	candidates, candidateStrategies := []string{}, []string{}
+	for _, strategy := range strategies {
+		var query string
+		switch strategy {
+		case REFORMULATEADD:
+			query = reformulate.addTerm(terms)
+		case REFORMULATEREMOVE:
+			if len(terms) > 1 {
+				i := rand.Intn(len(terms))
+				query = strings.Join(append(append([]string{}, terms[:i]...), terms[i+1:]...), " ")
+			}
+		case REFORMULATESYNONYM:
+			query = reformulate.swapSynonym(terms)
+		case REFORMULATEFIXTYPO:
+			if v.LastResponse != nil && len(v.LastResponse.QueryCorrections) > 0 {
+				query = v.LastResponse.QueryCorrections[0].CorrectedQuery
+			}
+		}
+		if query != "" && query != v.LastQuery.Q {
+			candidates = append(candidates, query)
+			candidateStrategies = append(candidateStrategies, strategy)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", "", false
+	}
+	i := rand.Intn(len(candidates))
+	return candidates[i], candidateStrategies[i], true
+}
+
+// addTerm Returns the query with a term of the pool it does not contain yet.
+func (reformulate *ReformulateEvent) addTerm(terms []string) string {
+	pool := []string{}
+	for _, term := range reformulate.Terms {
+		if !containsFold(terms, term) {
+			pool = append(pool, term)
+		}
+	}
+	if len(pool) == 0 {
+		return ""
+	}
+	return strings.Join(append(append([]string{}, terms...), pool[rand.Intn(len(pool))]), " ")
+}
+
+// swapSynonym Returns the query with one of its terms replaced by a synonym.
+func (reformulate *ReformulateEvent) swapSynonym(terms []string) string {
+	swappable := []int{}
+	for i, term := range terms {
+		if len(reformulate.synonymsOf(term)) > 0 {
+			swappable = append(swappable, i)
+		}
+	}
+	if len(swappable) == 0 {
+		return ""
+	}
+	i := swappable[rand.Intn(len(swappable))]
+	synonyms := reformulate.synonymsOf(terms[i])
+	swapped := append([]string{}, terms...)
+	swapped[i] = synonyms[rand.Intn(len(synonyms))]
+	return strings.Join(swapped, " ")
+}
+
+// synonymsOf Returns the synonyms of a term, ignoring the case.
+func (reformulate *ReformulateEvent) synonymsOf(term string) []string {
+	for key, synonyms := range reformulate.Synonyms {
+		if strings.EqualFold(key, term) {
+			return synonyms
+		}
+	}
+	return nil
+}
+
+// containsFold Returns true if the terms contain the term, ignoring the case.
+func containsFold(terms []string, term string) bool {
+	for _, t := range terms {
+		if strings.EqualFold(t, term) {
+			return true
+		}
+	}
+	return false
+}
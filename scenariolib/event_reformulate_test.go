@@ -0,0 +1,127 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestReformulateEventValid(t *testing.T) {
+	var testEventJson = []byte(`{"strategies": ["add", "synonym"], "terms": ["setup"], "synonyms": {"setup": ["install"]}, "times": 3, "matchField": "title", "matchPattern": "^VPN", "customData": {"data1": "one"}}`)
+	event := &scenariolib.ReformulateEvent{}
+
+	// Test unmarshal json.
+	err := json.Unmarshal(testEventJson, event)
+	ok(t, err)
+
+	valid, message := event.IsValid()
+	assert(t, valid, "Expected event to be valid, was false with error: %s", message)
+
+	equals(t, []string{"add", "synonym"}, event.Strategies)
+	equals(t, []string{"install"}, event.Synonyms["setup"])
+	equals(t, 3, event.Times)
+
+	// Expect CustomData["data1"] to be "one"
+	equals(t, "one", event.CustomData["data1"])
+}
+
+func TestReformulateEventInvalid(t *testing.T) {
+	var testEvents = [][]byte{
+		[]byte(`{"strategies": ["shuffle"]}`),
+		[]byte(`{"times": -1}`),
+		[]byte(`{"matchPattern": "^VPN"}`),
+		[]byte(`{"matchField": "title", "matchPattern": "(VPN"}`),
+	}
+
+	for _, testEventJson := range testEvents {
+		event := &scenariolib.ReformulateEvent{}
+		err := json.Unmarshal(testEventJson, event)
+		ok(t, err)
+
+		valid, _ := event.IsValid()
+		assert(t, !valid, "Expected event %s to be invalid.", testEventJson)
+	}
+}
+
+func TestReformulateEventExecute(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// Only the queries about windows find the windows guide, "vnp" is corrected to "vpn" when asked.
+	queries := []string{}
+	actionCauses := []string{}
+	customData := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		if req.URL.Path == defaults.ANALYTICS_REST_PATH+"search/" {
+			actionCauses = append(actionCauses, body["actionCause"].(string))
+			customData = append(customData, body["customData"].(map[string]interface{}))
+		}
+		if req.URL.Path == defaults.SEARCH_REST_PATH {
+			q := body["q"].(string)
+			queries = append(queries, q)
+			switch {
+			case q == "vnp" && body["enableDidYouMean"] == true:
+				rw.Write([]byte(`{"totalCount": 0, "queryCorrections": [{"correctedQuery": "vpn"}]}`))
+			case strings.Contains(q, "windows"):
+				rw.Write([]byte(`{"totalCount": 1, "results": [{"uri": "uri", "raw": {"urihash": "hash", "title": "Windows VPN guide"}}]}`))
+			default:
+				rw.Write([]byte(`{"totalCount": 1, "results": [{"uri": "uri", "raw": {"urihash": "hash", "title": "VPN"}}]}`))
+			}
+			return
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	// Following the correction is a click on Did You Mean, whatever the action cause of the event.
+	// The search did not ask for corrections, the event asks the index for them.
+	ok(t, (&scenariolib.SearchEvent{Query: "vnp"}).Execute(v))
+	actionCauses = actionCauses[:0]
+	customData = customData[:0]
+	ok(t, (&scenariolib.ReformulateEvent{Strategies: []string{"fixTypo", "remove"}, ActionCause: "searchboxAsYouType", CustomData: map[string]interface{}{"data1": "one"}}).Execute(v))
+	equals(t, "vpn", v.LastQuery.Q)
+	assert(t, !v.LastQuery.EnableDidYouMean, "Expected the event to restore enableDidYouMean.")
+	equals(t, []string{"didyoumeanClick"}, actionCauses)
+	equals(t, "vnp", customData[0]["originalQuery"])
+	equals(t, "one", customData[0]["data1"])
+
+	// The terms are added until the windows guide shows up.
+	queries = queries[:0]
+	event := &scenariolib.ReformulateEvent{Strategies: []string{"add"}, Terms: []string{"setup", "windows", "vpn"}, Times: 5, MatchField: "title", MatchPattern: "^Windows"}
+	valid, message := event.IsValid()
+	assert(t, valid, "Expected event to be valid, was false with error: %s", message)
+	ok(t, event.Execute(v))
+	assert(t, len(queries) > 0 && len(queries) <= 2, "Expected one or two reformulations, got %v", queries)
+	assert(t, strings.HasPrefix(v.LastQuery.Q, "vpn ") && strings.Contains(v.LastQuery.Q, "windows"), "Expected the query to end with windows, was %s", v.LastQuery.Q)
+
+	// The other strategies send the action cause of the event, or the default one.
+	ok(t, (&scenariolib.SearchEvent{Query: "vpn setup"}).Execute(v))
+	actionCauses = actionCauses[:0]
+	ok(t, (&scenariolib.ReformulateEvent{Strategies: []string{"synonym"}, Synonyms: map[string][]string{"Setup": {"install"}}, ActionCause: "searchboxAsYouType"}).Execute(v))
+	equals(t, "vpn install", v.LastQuery.Q)
+	ok(t, (&scenariolib.ReformulateEvent{Strategies: []string{"remove"}}).Execute(v))
+	equals(t, []string{"searchboxAsYouType", "searchboxSubmit"}, actionCauses)
+
+	// Nothing left to add, the query stays the same.
+	ok(t, (&scenariolib.SearchEvent{Query: "vpn install"}).Execute(v))
+	queries = queries[:0]
+	ok(t, (&scenariolib.ReformulateEvent{Strategies: []string{"add"}, Terms: []string{"VPN", "install"}}).Execute(v))
+	equals(t, 0, len(queries))
+}
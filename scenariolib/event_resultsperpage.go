@@ -0,0 +1,46 @@
+package scenariolib
+
+import (
+	"errors"
+)
+
+// ============== RESULTS PER PAGE EVENT ======================
+// ============================================================
+
+// ResultsPerPageEvent represents the user changing the number of results per page
+type ResultsPerPageEvent struct {
+	NumberOfResults int                    `json:"numberOfResults"`
+	CustomData      map[string]interface{} `json:"customData,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (resultsPerPage *ResultsPerPageEvent) IsValid() (bool, string) {
+	if resultsPerPage.NumberOfResults < 1 {
+		return false, "numberOfResults must be a positive integer."
+	}
+	return true, ""
+}
+
+// Execute Change the number of results of the query, run it again and send the pagerResize
+// event to the analytics
+func (resultsPerPage *ResultsPerPageEvent) Execute(v *Visit) error {
+	if v.LastResponse == nil {
+		return errors.New("No query before ResultsPerPage event, use a search event first")
+	}
+	Info.Printf("Showing %d results per page", resultsPerPage.NumberOfResults)
+
+	v.LastQuery.NumberOfResults = resultsPerPage.NumberOfResults
+	v.LastQuery.FirstResult = 0
+
+	resp, err := v.SearchClient.Query(*v.LastQuery)
+	if err != nil {
+		return err
+	}
+	v.LastResponse = resp
+
+	if resultsPerPage.CustomData == nil {
+		resultsPerPage.CustomData = make(map[string]interface{})
+	}
+	resultsPerPage.CustomData["currentResultsPerPage"] = resultsPerPage.NumberOfResults
+	return v.sendInterfaceChangeEvent("pagerResize", "getMoreResults", resultsPerPage.CustomData)
+}
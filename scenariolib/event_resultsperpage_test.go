@@ -0,0 +1,78 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestResultsPerPageEventValid(t *testing.T) {
+	var testEventJson = []byte(`{"numberOfResults": 50, "customData": {"data1": "one"}}`)
+	event := &scenariolib.ResultsPerPageEvent{}
+
+	// Test unmarshal json.
+	err := json.Unmarshal(testEventJson, event)
+	ok(t, err)
+
+	valid, message := event.IsValid()
+	assert(t, valid, "Expected event to be valid, was false with error: %s", message)
+
+	equals(t, 50, event.NumberOfResults)
+
+	// Expect CustomData["data1"] to be "one"
+	equals(t, "one", event.CustomData["data1"])
+}
+
+func TestResultsPerPageEventInvalid(t *testing.T) {
+	event := &scenariolib.ResultsPerPageEvent{}
+	valid, _ := event.IsValid()
+	assert(t, !valid, "Expected event without numberOfResults to be invalid.")
+}
+
+func TestResultsPerPageEventExecute(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// All requests caught by the Test server will be added to 'requests'
+	requests := make(map[string]RestRequest)
+
+	server := createTestServer(t, requests)
+	defer server.Close() // Close the server when test finishes
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.LastQuery.Q = "test"
+	v.LastQuery.FirstResult = 20
+	v.LastResponse = &search.Response{TotalCount: 50}
+
+	event := &scenariolib.ResultsPerPageEvent{NumberOfResults: 50}
+	err = event.Execute(v)
+	ok(t, err)
+
+	equals(t, 50, v.LastQuery.NumberOfResults)
+	equals(t, 0, v.LastQuery.FirstResult)
+
+	req, exists := requests["/rest/search/"]
+	assert(t, exists, "Missing request for /rest/search/")
+	query := &search.Query{}
+	ok(t, json.Unmarshal(req.Body, query))
+	equals(t, 50, query.NumberOfResults)
+	equals(t, 0, query.FirstResult)
+
+	req, exists = requests["/rest/v15/analytics/search/"]
+	assert(t, exists, "Missing request for /rest/v15/analytics/search/")
+	body := map[string]interface{}{}
+	ok(t, json.Unmarshal(req.Body, &body))
+	equals(t, "pagerResize", body["actionCause"])
+	equals(t, 50.0, body["customData"].(map[string]interface{})["currentResultsPerPage"])
+}
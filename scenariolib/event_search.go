@@ -5,7 +5,6 @@ package scenariolib
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 
 	ua "github.com/coveooss/go-coveo/analytics"
 )
@@ -23,6 +22,10 @@ type SearchEvent struct {
 	InputTitle    string                 `json:"inputTitle,omitempty"`
 	MatchLanguage bool                   `json:"matchLanguage,omitempty"`
 	CustomData    map[string]interface{} `json:"customData,omitempty"`
+	Context       map[string]interface{} `json:"context,omitempty"`
+	DidYouMean    bool                   `json:"didYouMean,omitempty"`
+	TypoQuery     bool                   `json:"typoQuery,omitempty"`
+	TemplateQuery bool                   `json:"templateQuery,omitempty"`
 	Keywords      string
 	ActionType    string
 }
@@ -30,12 +33,17 @@ type SearchEvent struct {
 const caseQuerySomeTemplate = "($some(keywords: '%s', match: 1, maximum: 300))"
 const defaultSearchCause = "searchboxSubmit"
 const defaultCaseSearchCause = "inputChange"
+const didYouMeanAutomaticCause = "didyoumeanAutomatic"
+const didYouMeanClickCause = "didyoumeanClick"
 
 // IsValid Additional validation after the json unmarshal.
 func (search *SearchEvent) IsValid() (bool, string) {
 	if search.CaseSearch && search.InputTitle == "" {
 		return false, "If caseSearch is true, you need to provide an inputTitle."
 	}
+	if search.CaseSearch && search.DidYouMean {
+		return false, "didYouMean cannot be used with a caseSearch."
+	}
 	return true, ""
 }
 
@@ -43,26 +51,39 @@ func (search *SearchEvent) handleCaseSearch() {
 	Info.Println("Executing a Case Search.")
 	search.ActionCause = defaultCaseSearchCause
 	search.ActionType = "caseCreation"
-	search.Query = fmt.Sprintf(caseQuerySomeTemplate, search.Keywords)
+	search.Query = caseSearchQuery(search.Keywords)
 	if search.CustomData == nil {
 		search.CustomData = make(map[string]interface{})
 	}
 	search.CustomData["inputTitle"] = search.InputTitle
 }
 
+// caseSearchQuery Returns the advanced query of a case search for the keywords.
+func caseSearchQuery(keywords string) string {
+	return fmt.Sprintf(caseQuerySomeTemplate, keywords)
+}
+
 // Execute the search event, runs the query and sends a search event to
 // the analytics. Returns an error if something went wrong.
 func (search *SearchEvent) Execute(visit *Visit) (err error) {
 
+	if search.Query == "" && search.TemplateQuery { // generate the query from a template
+		if search.Query, err = visit.Config.QueryTemplates.Generate(visit.Language, search.MatchLanguage); err != nil {
+			return
+		}
+	}
 	if search.Query == "" { // if the query is empty, randomize one
 		var queriesToRandom []string
 		if queriesToRandom, err = search.getQueriesToRandomize(visit); err != nil { // Figure out from which queries to randomize
 			return
 		}
-		if search.Query, err = randomQuery(queriesToRandom); err != nil { // Randomize the query from the selected array
+		if search.Query, err = randomQuery(queriesToRandom, visit.Config.QueryPopularity); err != nil { // Randomize the query from the selected array
 			return
 		}
 	}
+	if search.TypoQuery { // misspell the query
+		search.Query = visit.Config.Typos.Mutate(search.Query)
+	}
 	search.Keywords = search.Query
 	if search.ActionCause == "" {
 		search.ActionCause = defaultSearchCause
@@ -70,16 +91,29 @@ func (search *SearchEvent) Execute(visit *Visit) (err error) {
 	if search.CaseSearch {
 		search.handleCaseSearch()
 		visit.LastQuery.AQ = search.Query
+		// Keep the facet values selected by the user
+		visit.applyFacetState()
 	} else {
 		visit.LastQuery.Q = search.Query
 	}
+	// A new query always shows the first page of results
+	visit.LastQuery.FirstResult = 0
+	visit.SetContext(search.Context)
 	Info.Printf("Searching for : %s", search.Keywords)
 
 	// Execute a search and save the response
-	if visit.LastResponse, err = visit.SearchClient.Query(*visit.LastQuery); err != nil {
+	enableDidYouMean := visit.LastQuery.EnableDidYouMean
+	visit.LastQuery.EnableDidYouMean = enableDidYouMean || search.DidYouMean
+	visit.LastResponse, err = visit.SearchClient.Query(*visit.LastQuery)
+	visit.LastQuery.EnableDidYouMean = enableDidYouMean
+	if err != nil {
 		return
 	}
 
+	if search.DidYouMean && len(visit.LastResponse.QueryCorrections) > 0 {
+		return search.followCorrection(visit)
+	}
+
 	// in some scenarios (logging of page views), we don't want to send the search event to the analytics
 	if !search.IgnoreEvent {
 		return search.send(visit)
@@ -121,10 +155,50 @@ func (search *SearchEvent) send(visit *Visit) error {
 	return visit.SendSearchEvent(event)
 }
 
+// followCorrection Search for the query correction of the response. The index corrects the query
+// automatically when there are no results, otherwise the user clicks on the "Did you mean" link
+// after the original search event is sent.
+func (search *SearchEvent) followCorrection(visit *Visit) error {
+	actionCause := didYouMeanClickCause
+	if visit.LastResponse.TotalCount == 0 {
+		actionCause = didYouMeanAutomaticCause
+	} else if !search.IgnoreEvent {
+		if err := search.send(visit); err != nil {
+			return err
+		}
+	}
+
+	corrected := visit.LastResponse.QueryCorrections[0].CorrectedQuery
+	Info.Printf("Following the correction of %s : %s", search.Keywords, corrected)
+	visit.LastQuery.Q = corrected
+	resp, err := visit.SearchClient.Query(*visit.LastQuery)
+	if err != nil {
+		return err
+	}
+	visit.LastResponse = resp
+
+	if search.IgnoreEvent {
+		Info.Println("Ignoring the search event because of configuration.")
+		return nil
+	}
+	correction := &SearchEvent{Keywords: corrected, ActionCause: actionCause, CustomData: correctionCustomData(search.CustomData, search.Keywords)}
+	return correction.send(visit)
+}
+
+// correctionCustomData Returns the customData of the search for a query correction, with the original query.
+func correctionCustomData(customData map[string]interface{}, originalQuery string) map[string]interface{} {
+	correction := make(map[string]interface{})
+	for k, v := range customData {
+		correction[k] = v
+	}
+	correction["originalQuery"] = originalQuery
+	return correction
+}
+
 // getQueriesToRandomize Return an array of queries to randomize from.
 func (search *SearchEvent) getQueriesToRandomize(visit *Visit) (queriesToRandom []string, err error) {
-	if search.GoodQuery { // if we want a good query
-		queriesToRandom = visit.Config.GoodQueries
+	if search.GoodQuery || search.TypoQuery { // if we want a good query, or a typo of a good query
+		queriesToRandom = append(append([]string{}, visit.Config.GoodQueries...), visit.Config.HarvestedGoodQueries...)
 		if search.MatchLanguage { // if the query must match the language
 			if _, ok := visit.Config.GoodQueriesInLang[visit.Language]; !ok {
 				err = errors.New("No good query detected in " + visit.Language)
@@ -133,7 +207,7 @@ func (search *SearchEvent) getQueriesToRandomize(visit *Visit) (queriesToRandom
 			queriesToRandom = visit.Config.GoodQueriesInLang[visit.Language]
 		}
 	} else { // if we want a bad query
-		queriesToRandom = visit.Config.BadQueries
+		queriesToRandom = append(append([]string{}, visit.Config.BadQueries...), visit.Config.HarvestedBadQueries...)
 		if search.MatchLanguage { // if the query must match the language
 			if _, ok := visit.Config.BadQueriesInLang[visit.Language]; !ok {
 				err = errors.New("No bad query detected in " + visit.Language)
@@ -145,14 +219,14 @@ func (search *SearchEvent) getQueriesToRandomize(visit *Visit) (queriesToRandom
 	return
 }
 
-// randomQuery Returns a random query good or bad from the list of possible queries.
-// returns an error if there are no queries to select from
-func randomQuery(queries []string) (query string, err error) {
+// randomQuery Returns a random query good or bad from the list of possible queries, following
+// their popularity. returns an error if there are no queries to select from
+func randomQuery(queries []string, popularity QueryPopularity) (query string, err error) {
 	if len(queries) < 1 {
 		err = errors.New("Queries are empty")
 		return
 	}
 
-	query = queries[rand.Intn(len(queries))]
+	query = popularity.pick(queries)
 	return
 }
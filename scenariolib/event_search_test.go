@@ -2,8 +2,12 @@ package scenariolib_test
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/coveo/uabot/defaults"
 	"github.com/coveo/uabot/scenariolib"
 )
 
@@ -38,3 +42,67 @@ func TestSearchEventValid(t *testing.T) {
 	// Expect CustomData["data1"] to be "one"
 	equals(t, "one", event.CustomData["data1"])
 }
+
+func TestSearchEventDidYouMean(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+	// The index corrects the typos of "gostbuster" and "ghostbustr", only the latter has results.
+	queries := []map[string]interface{}{}
+	searchEvents := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch req.URL.Path {
+		case defaults.SEARCH_REST_PATH:
+			queries = append(queries, body)
+			switch body["q"] {
+			case "gostbuster":
+				rw.Write([]byte(`{"totalCount": 0, "queryCorrections": [{"correctedQuery": "ghostbuster"}]}`))
+			case "ghostbustr":
+				rw.Write([]byte(`{"totalCount": 1, "results": [{"uri": "uri", "raw": {"urihash": "hash"}}], "queryCorrections": [{"correctedQuery": "ghostbuster"}]}`))
+			default:
+				rw.Write([]byte(`{"totalCount": 1, "results": [{"uri": "uri", "raw": {"urihash": "hash"}}]}`))
+			}
+			return
+		case defaults.ANALYTICS_REST_PATH + "search/":
+			searchEvents = append(searchEvents, body)
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	// No results, the query is corrected automatically and only the correction is logged.
+	ok(t, (&scenariolib.SearchEvent{Query: "gostbuster", DidYouMean: true}).Execute(v))
+	equals(t, 2, len(queries))
+	equals(t, true, queries[0]["enableDidYouMean"])
+	equals(t, "ghostbuster", v.LastQuery.Q)
+	assert(t, !v.LastQuery.EnableDidYouMean, "Expected enableDidYouMean to be reset after the search.")
+	equals(t, 1, len(searchEvents))
+	equals(t, "didyoumeanAutomatic", searchEvents[0]["actionCause"])
+	equals(t, "ghostbuster", searchEvents[0]["queryText"])
+	equals(t, "gostbuster", searchEvents[0]["customData"].(map[string]interface{})["originalQuery"])
+
+	// With results, the original search is logged before the click on the correction.
+	searchEvents = searchEvents[:0]
+	ok(t, (&scenariolib.SearchEvent{Query: "ghostbustr", DidYouMean: true}).Execute(v))
+	equals(t, 2, len(searchEvents))
+	equals(t, "searchboxSubmit", searchEvents[0]["actionCause"])
+	equals(t, "didyoumeanClick", searchEvents[1]["actionCause"])
+	equals(t, "ghostbustr", searchEvents[1]["customData"].(map[string]interface{})["originalQuery"])
+
+	// Without didYouMean, the correction is ignored.
+	searchEvents = searchEvents[:0]
+	ok(t, (&scenariolib.SearchEvent{Query: "ghostbustr"}).Execute(v))
+	equals(t, 1, len(searchEvents))
+	equals(t, "ghostbustr", v.LastQuery.Q)
+}
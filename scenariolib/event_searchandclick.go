@@ -2,6 +2,7 @@ package scenariolib
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"regexp"
 )
@@ -10,7 +11,7 @@ import (
 // ============================================================
 
 // SearchAndClickEvent represents a search event followed by a click on a specific
-// document found by the title
+// document found by the title, a field pattern, a target or the first target of a pool found
 type SearchAndClickEvent struct {
 	Query        string                 `json:"queryText"`
 	Probability  float64                `json:"probability"`
@@ -21,12 +22,31 @@ type SearchAndClickEvent struct {
 	CaseSearch   bool                   `json:"caseSearch,omitempty"`
 	InputTitle   string                 `json:"inputTitle,omitempty"`
 	CustomData   map[string]interface{} `json:"customData,omitempty"`
+	Context      map[string]interface{} `json:"context,omitempty"`
+	ClickModel   *ClickModel            `json:"clickModel,omitempty"`
+	Dwell        *Dwell                 `json:"dwell,omitempty"`
+	Target       *ClickTarget           `json:"target,omitempty"`
+	Targets      []*ClickTarget         `json:"targets,omitempty"`
+	Fallback     string                 `json:"fallback,omitempty"`
+	FallbackRank int                    `json:"fallbackRank,omitempty"`
 	RegexMatch   *regexp.Regexp
 }
 
 // IsValid Additional validation after the json unmarshal. And compilation of the regex if available.
 func (searchClick *SearchAndClickEvent) IsValid() (bool, string) {
-	if searchClick.DocTitle == "" {
+	if searchClick.Target != nil || len(searchClick.Targets) > 0 {
+		if searchClick.DocTitle != "" || searchClick.MatchField != "" || searchClick.MatchPattern != "" {
+			return false, "If you provide a [target] or [targets] you cannot also use [docClickTitle] or [matchField and matchPattern]"
+		}
+		if searchClick.Target != nil && len(searchClick.Targets) > 0 {
+			return false, "You cannot use both [target] and [targets]"
+		}
+		for _, target := range searchClick.pool() {
+			if valid, message := target.IsValid(); !valid {
+				return false, message
+			}
+		}
+	} else if searchClick.DocTitle == "" {
 		if searchClick.MatchField == "" || searchClick.MatchPattern == "" {
 			return false, "If you are not using a [docClickTitle] you must provide both [matchField and matchPattern]"
 		}
@@ -35,11 +55,30 @@ func (searchClick *SearchAndClickEvent) IsValid() (bool, string) {
 			return false, "If you provide a [docClickTitle] you cannot also use [matchField and/or matchPattern]"
 		}
 	}
+
+	switch searchClick.Fallback {
+	case "", FALLBACKFAIL, FALLBACKRANK, FALLBACKRANDOM, FALLBACKABANDON:
+	default:
+		return false, fmt.Sprintf("Fallback %s is not supported.", searchClick.Fallback)
+	}
+	if searchClick.FallbackRank < 0 {
+		return false, "fallbackRank must be a positive integer."
+	}
+
 	var err error
 	if searchClick.RegexMatch, err = regexp.Compile(searchClick.MatchPattern); err != nil {
 		return false, "Failed to compile regex pattern : " + err.Error()
 	}
 
+	if searchClick.ClickModel != nil {
+		if valid, message := searchClick.ClickModel.IsValid(); !valid {
+			return false, message
+		}
+	}
+	if searchClick.Dwell != nil {
+		return searchClick.Dwell.IsValid()
+	}
+
 	return true, ""
 }
 
@@ -51,6 +90,7 @@ func (searchClick *SearchAndClickEvent) Execute(v *Visit) error {
 	search.Query = searchClick.Query
 	search.Keywords = searchClick.Query
 	search.CustomData = make(map[string]interface{})
+	search.Context = searchClick.Context
 	if searchClick.CaseSearch {
 		search.CaseSearch = searchClick.CaseSearch
 		search.InputTitle = searchClick.InputTitle
@@ -64,6 +104,13 @@ func (searchClick *SearchAndClickEvent) Execute(v *Visit) error {
 		return err
 	}
 
+	if len(v.LastResponse.Results) == 0 {
+		switch searchClick.Fallback {
+		case FALLBACKRANK, FALLBACKRANDOM, FALLBACKABANDON:
+			Warning.Printf("Last query %s returned no results, the user abandons the search", v.LastQuery.Q)
+			return nil
+		}
+	}
 	if v.LastResponse.TotalCount < 1 {
 		return errors.New("Last query returned no results")
 	}
@@ -77,11 +124,19 @@ func (searchClick *SearchAndClickEvent) Execute(v *Visit) error {
 	WaitBetweenActions(timeToWait, v.Config.IsWaitConstant)
 
 	if rand.Float64() <= searchClick.Probability {
-		var rank int
-		if searchClick.MatchField != "" {
-			rank = v.FindDocumentRankByMatchingField(searchClick.MatchField, searchClick.RegexMatch)
-		} else {
-			rank = v.FindDocumentRankByTitle(searchClick.DocTitle)
+		rank := searchClick.FindTargetRank(v)
+		if rank < 0 {
+			switch searchClick.Fallback {
+			case FALLBACKRANK:
+				rank = Min(searchClick.FallbackRank, len(v.LastResponse.Results)-1)
+				Info.Printf("Target not found, clicking the fallback rank %d", rank+1)
+			case FALLBACKRANDOM:
+				rank = computeClickRank(v, -1, 0)
+				Info.Printf("Target not found, clicking a random rank %d", rank+1)
+			case FALLBACKABANDON:
+				Info.Println("Target not found, the user abandons the search")
+				return nil
+			}
 		}
 		if rank >= 0 {
 			Info.Printf("Sending ClickEvent => Found document at rank : %d", rank+1)
@@ -91,6 +146,8 @@ func (searchClick *SearchAndClickEvent) Execute(v *Visit) error {
 			click.Offset = 0
 			click.Probability = 1
 			click.Quickview = searchClick.Quickview
+			click.ClickModel = searchClick.ClickModel
+			click.Dwell = searchClick.Dwell
 
 			click.CustomData = make(map[string]interface{})
 			// Override possible values of customData with the specific customData sent
@@ -109,3 +166,28 @@ func (searchClick *SearchAndClickEvent) Execute(v *Visit) error {
 
 	return nil
 }
+
+// pool Returns the targets of the event, a single target being a pool of one.
+func (searchClick *SearchAndClickEvent) pool() []*ClickTarget {
+	if searchClick.Target != nil {
+		return []*ClickTarget{searchClick.Target}
+	}
+	return searchClick.Targets
+}
+
+// FindTargetRank Returns the rank of the document to click in the last response, -1 when not
+// found. The targets of a pool are tried in a random order following their weights.
+func (searchClick *SearchAndClickEvent) FindTargetRank(v *Visit) int {
+	if pool := searchClick.pool(); len(pool) > 0 {
+		for _, target := range shuffleTargets(pool) {
+			if rank := v.FindDocumentRankByTarget(target); rank >= 0 {
+				return rank
+			}
+		}
+		return -1
+	}
+	if searchClick.MatchField != "" {
+		return v.FindDocumentRankByMatchingField(searchClick.MatchField, searchClick.RegexMatch)
+	}
+	return v.FindDocumentRankByTitle(searchClick.DocTitle)
+}
@@ -2,6 +2,7 @@ package scenariolib_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 
@@ -34,6 +35,15 @@ func TestSearchAndClickEventValid(t *testing.T) {
 	equals(t, "one", event.CustomData["data1"])
 }
 
+// visitIdentity Returns the JSON of the user of the visit in the analytics events, both the
+// user and the ip of the visit are random.
+func visitIdentity(v *scenariolib.Visit) string {
+	if v.Anonymous {
+		return `"anonymous": true`
+	}
+	return fmt.Sprintf(`"username": %q`, v.Username)
+}
+
 func TestDecorateSearchAndClickEvent(t *testing.T) {
 	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
 
@@ -77,22 +87,22 @@ func TestDecorateSearchAndClickEvent(t *testing.T) {
 	equals(t, "POST", req.Method)
 	equals(t, "Bearer bot.analyticsToken", req.Headers["Authorization"])
 	equals(t, "application/json", req.Headers["Content-Type"])
-	expectedBody = []byte(`{
+	expectedBody = []byte(fmt.Sprintf(`{
 		"language": "en",
 		"device": "Bot",
 		"customData": {
 			"JSUIVersion": "0.0.0.0;0.0.0.0",
 			"customData1": "customValue 1",
-			"ipaddress": "216.249.112.8"
+			"ipaddress": %q
 		},
-		"anonymous": true,
+		%s,
 		"originLevel1": "BotSearch",
 		"originLevel2": "",
 		"searchQueryUid": "",
 		"queryText": "aaaaaaaaaaa",
 		"actionCause": "searchboxSubmit",
 		"contextual": false
-	}`)
+	}`, v.IP, visitIdentity(v)))
 	eq, err = JSONBytesEqual(expectedBody, req.Body)
 	assert(t, eq, "The Request's body for Analytics is not what we expected\nGot: %s\nExp: %s", expectedBody, req.Body)
 }
@@ -129,7 +139,8 @@ func TestDecorateSearchAndClickEvent2(t *testing.T) {
 		"q": "Gostbuster",
 		"numberOfResults": 20,
 		"tab": "All",
-		"pipeline": "ML"
+		"pipeline": "ML",
+		"searchHub": "Movie"
 	}`)
 	eq, err := JSONBytesEqual(expectedBody, req.Body)
 	assert(t, eq, "The Request's body for Search is not what we expected\nGot: %s\nExp: %s", expectedBody, req.Body)
@@ -140,22 +151,22 @@ func TestDecorateSearchAndClickEvent2(t *testing.T) {
 	equals(t, "POST", req.Method)
 	equals(t, "Bearer bot.analyticsToken", req.Headers["Authorization"])
 	equals(t, "application/json", req.Headers["Content-Type"])
-	expectedBody = []byte(`{
+	expectedBody = []byte(fmt.Sprintf(`{
 		"language": "en",
 		"device": "Bot",
 		"customData": {
 			"JSUIVersion": "0.0.0.0;0.0.0.0",
 			"c_isbot": "true",
-			"ipaddress": "198.199.154.209"
+			"ipaddress": %q
 		},
-		"username": "avery.caldwell@hexzone.com",
+		%s,
 		"originLevel1": "Movie",
 		"originLevel2": "default",
 		"searchQueryUid": "",
 		"queryText": "Gostbuster",
 		"actionCause": "searchboxSubmit",
 		"contextual": false
-	}`)
+	}`, v.IP, visitIdentity(v)))
 	eq, err = JSONBytesEqual(expectedBody, req.Body)
 	assert(t, eq, "The Request's body for Analytics is not what we expected\nGot: %s\nExp: %s", expectedBody, req.Body)
 }
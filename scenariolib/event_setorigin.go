@@ -20,6 +20,10 @@ func (origin *SetOriginEvent) Execute(v *Visit) error {
 	Info.Printf("Executing SetOrigin {originLevel1: %s, originLevel2: %s, OriginLevel3: %s}", origin.OriginLevel1, origin.OriginLevel2, origin.OriginLevel3)
 	if origin.OriginLevel1 != "" {
 		v.OriginLevel1 = origin.OriginLevel1
+		// The search hub of the queries follows the page the user is on.
+		if v.LastQuery != nil && v.LastQuery.SearchHub != "" {
+			v.LastQuery.SearchHub = origin.OriginLevel1
+		}
 	}
 	if origin.OriginLevel2 != "" {
 		v.OriginLevel2 = origin.OriginLevel2
@@ -0,0 +1,50 @@
+package scenariolib
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ============== SORT CHANGE EVENT ======================
+// =======================================================
+
+// sortCriteriaPattern The sort criteria supported by the search API.
+var sortCriteriaPattern = regexp.MustCompile(`^(relevancy|qre|(date|@[A-Za-z0-9_]+) (ascending|descending))$`)
+
+// SortChangeEvent represents the user changing the sort of the results
+type SortChangeEvent struct {
+	SortCriteria string                 `json:"sortCriteria"`
+	CustomData   map[string]interface{} `json:"customData,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (sort *SortChangeEvent) IsValid() (bool, string) {
+	if !sortCriteriaPattern.MatchString(sort.SortCriteria) {
+		return false, "sortCriteria must be relevancy, qre, date ascending, date descending, @field ascending or @field descending."
+	}
+	return true, ""
+}
+
+// Execute Change the sort of the query, run it again and send the resultsSort event
+// to the analytics
+func (sort *SortChangeEvent) Execute(v *Visit) error {
+	if v.LastResponse == nil {
+		return errors.New("No query before SortChange event, use a search event first")
+	}
+	Info.Printf("Sorting results by %s", sort.SortCriteria)
+
+	v.LastQuery.SortCriteria = sort.SortCriteria
+	v.LastQuery.FirstResult = 0
+
+	resp, err := v.SearchClient.Query(*v.LastQuery)
+	if err != nil {
+		return err
+	}
+	v.LastResponse = resp
+
+	if sort.CustomData == nil {
+		sort.CustomData = make(map[string]interface{})
+	}
+	sort.CustomData["resultsSortBy"] = sort.SortCriteria
+	return v.sendInterfaceChangeEvent("resultsSort", "misc", sort.CustomData)
+}
@@ -24,6 +24,7 @@ func (tab *TabChangeEvent) Execute(v *Visit) error {
 	v.LastQuery.CQ = v.LastQuery.CQ + " " + tab.ConstantExpression
 	v.OriginLevel2 = tab.Name
 	v.LastQuery.Tab = tab.Name
+	v.LastQuery.FirstResult = 0
 
 	resp, err := v.SearchClient.Query(*v.LastQuery)
 	if err != nil {
@@ -14,21 +14,48 @@ func ParseEvent(e *JSONEvent, c *Config) (Event, error) {
 	var event Event
 	switch e.Type {
 
+	case "BreadcrumbResetAll":
+		event = &BreadcrumbResetAllEvent{}
+
+	case "CategoryFacet":
+		event = &CategoryFacetEvent{}
+
 	case "Click":
 		event = &ClickEvent{}
 
 	case "Custom":
 		event = &CustomEvent{}
 
-	case "FacetChange":
+	case "FacetChange", "FacetSelect":
 		event = &FacetEvent{}
 
+	case "FacetDeselect":
+		event = &FacetDeselectEvent{}
+
 	case "FakeSearch":
 		event = &FakeSearchEvent{}
 
+	case INCLUDEEVENTTYPE:
+		event = &IncludeEvent{}
+
+	case "JudgedSearch":
+		event = &JudgedSearchEvent{}
+
 	case "View":
 		event = &ViewEvent{}
 
+	case "OmniboxSearch":
+		event = &OmniboxSearchEvent{}
+
+	case "Paginate":
+		event = &PaginateEvent{}
+
+	case "Reformulate":
+		event = &ReformulateEvent{}
+
+	case "ResultsPerPage":
+		event = &ResultsPerPageEvent{}
+
 	case "Search":
 		event = &SearchEvent{}
 
@@ -41,6 +68,9 @@ func ParseEvent(e *JSONEvent, c *Config) (Event, error) {
 	case "SetReferrer":
 		event = &SetReferrerEvent{}
 
+	case "SortChange":
+		event = &SortChangeEvent{}
+
 	case "TabChange":
 		event = &TabChangeEvent{}
 
@@ -55,6 +85,11 @@ func ParseEvent(e *JSONEvent, c *Config) (Event, error) {
 	if valid, message := event.IsValid(); !valid {
 		return nil, errors.New(message)
 	}
+	for _, capture := range e.Capture {
+		if valid, message := capture.IsValid(); !valid {
+			return nil, errors.New(message)
+		}
+	}
 	return event, nil
 }
 
@@ -0,0 +1,242 @@
+package scenariolib
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// FACETPICKWEIGHTED Pick a facet value at random, weighted by its number of results.
+	FACETPICKWEIGHTED string = "weighted"
+	// FACETPICKRANDOM Pick a facet value at random, all values being equally likely.
+	FACETPICKRANDOM string = "random"
+	// FACETPICKTOP Pick a facet value at random among the values with the most results.
+	FACETPICKTOP string = "top"
+	// DEFAULTFACETVALUES The maximum number of values requested in the groupBy of a facet when not specified.
+	DEFAULTFACETVALUES int = 10
+)
+
+// FacetState The facet values selected by the user during the visit, by field. Values of
+// a field are OR-ed and the fields are AND-ed in the advanced query.
+type FacetState struct {
+	fields     []string
+	selections map[string][]string
+	// ranges The fields whose values are ranges, which are not quoted in the expression.
+	ranges map[string]bool
+	// expression The facet expression last applied to the advanced query.
+	expression string
+}
+
+// Select Add a value to the selected values of a field, returns false if it was already selected.
+func (state *FacetState) Select(field, value string) bool {
+	if state.selections == nil {
+		state.selections = make(map[string][]string)
+	}
+	for _, selected := range state.selections[field] {
+		if selected == value {
+			return false
+		}
+	}
+	if len(state.selections[field]) == 0 {
+		state.fields = append(state.fields, field)
+	}
+	state.selections[field] = append(state.selections[field], value)
+	return true
+}
+
+// SelectRange Add a range value such as 0..100 to the selected values of a field.
+func (state *FacetState) SelectRange(field, value string) bool {
+	if state.ranges == nil {
+		state.ranges = make(map[string]bool)
+	}
+	if len(state.selections[field]) == 0 {
+		state.ranges[field] = true
+	}
+	return state.Select(field, value)
+}
+
+// Deselect Remove a value from the selected values of a field, returns false if it was not selected.
+func (state *FacetState) Deselect(field, value string) bool {
+	values := state.selections[field]
+	for i, selected := range values {
+		if selected != value {
+			continue
+		}
+		state.selections[field] = append(values[:i:i], values[i+1:]...)
+		if len(state.selections[field]) == 0 {
+			delete(state.selections, field)
+			delete(state.ranges, field)
+			for j, f := range state.fields {
+				if f == field {
+					state.fields = append(state.fields[:j:j], state.fields[j+1:]...)
+					break
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Clear Deselect all the values of all the fields.
+func (state *FacetState) Clear() {
+	state.fields = nil
+	state.selections = nil
+	state.ranges = nil
+}
+
+// clearField Deselect all the values of a field.
+func (state *FacetState) clearField(field string) {
+	for _, value := range append([]string{}, state.Values(field)...) {
+		state.Deselect(field, value)
+	}
+}
+
+// Values Returns the selected values of a field.
+func (state *FacetState) Values(field string) []string {
+	return state.selections[field]
+}
+
+// Expression Returns the advanced query expression of the selected values.
+func (state *FacetState) Expression() string {
+	expressions := []string{}
+	for _, field := range state.fields {
+		values := state.selections[field]
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = value
+			if !state.ranges[field] {
+				quoted[i] = fmt.Sprintf("\"%s\"", value)
+			}
+		}
+		if len(quoted) == 1 {
+			expressions = append(expressions, fmt.Sprintf("%s==%s", field, quoted[0]))
+			continue
+		}
+		expressions = append(expressions, fmt.Sprintf("%s==(%s)", field, strings.Join(quoted, ",")))
+	}
+	return strings.Join(expressions, " ")
+}
+
+// applyFacetState Rebuild the advanced query of the visit from the facet state, replacing the
+// facet expression previously applied and keeping the rest of the advanced query.
+func (v *Visit) applyFacetState() {
+	base := v.LastQuery.AQ
+	if v.FacetState.expression != "" {
+		base = strings.TrimSuffix(base, v.FacetState.expression)
+	}
+	expression := v.FacetState.Expression()
+	v.LastQuery.AQ = strings.TrimSpace(strings.TrimSpace(base) + " " + expression)
+	v.FacetState.expression = expression
+	v.LastQuery.FirstResult = 0
+}
+
+// sendFacetEvent Run the query with the facet state applied and send the facet event to the analytics.
+func (v *Visit) sendFacetEvent(actionCause, actionType string, customData map[string]interface{}) error {
+	v.applyFacetState()
+
+	resp, err := v.SearchClient.Query(*v.LastQuery)
+	if err != nil {
+		return err
+	}
+	v.LastResponse = resp
+
+	return v.sendInterfaceChangeEvent(actionCause, actionType, customData)
+}
+
+// validFacetPick Validate the way a facet value is picked.
+func validFacetPick(pick string, top int) (bool, string) {
+	switch pick {
+	case "", FACETPICKWEIGHTED, FACETPICKRANDOM:
+	case FACETPICKTOP:
+		if top <= 0 {
+			return false, "Picking from the top facet values needs a positive top."
+		}
+	default:
+		return false, fmt.Sprintf("Facet pick %s is not supported.", pick)
+	}
+	return true, ""
+}
+
+// facetValues Returns the values of a facet. The groupBy results of the last response are used when the
+// page already requests the field, otherwise the last query is executed again with the groupBy request.
+func (v *Visit) facetValues(request *search.GroupByRequest) ([]search.GroupByValue, error) {
+	isCustom := request.GenerateAutomaticRanges || len(request.RangeValues) > 0 || len(request.AllowedValues) > 0
+	if !isCustom && v.LastResponse != nil {
+		for _, groupBy := range v.LastQuery.GroupByRequests {
+			if groupBy.Field == request.Field {
+				return groupByResultValues(v.LastResponse, request.Field), nil
+			}
+		}
+	}
+
+	query := *v.LastQuery
+	query.GroupByRequests = append(append([]*search.GroupByRequest{}, v.LastQuery.GroupByRequests...), request)
+	resp, err := v.SearchClient.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	return groupByResultValues(resp, request.Field), nil
+}
+
+// groupByResultValues Returns the values of the groupBy result of a field, the field is
+// returned without the @ by the search API.
+func groupByResultValues(resp *search.Response, field string) []search.GroupByValue {
+	for _, result := range resp.GroupByResults {
+		if strings.TrimPrefix(result.Field, "@") == strings.TrimPrefix(field, "@") {
+			return result.Values
+		}
+	}
+	return nil
+}
+
+// pickFacetValue Pick one of the values with the given strategy, values already selected are skipped.
+func (state *FacetState) pickFacetValue(field string, values []search.GroupByValue, pick string, top int) (string, error) {
+	candidates := []search.GroupByValue{}
+	for _, value := range values {
+		selected := false
+		for _, s := range state.Values(field) {
+			if s == value.Value {
+				selected = true
+				break
+			}
+		}
+		if !selected {
+			candidates = append(candidates, value)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", errors.New("No facet value left to select in " + field)
+	}
+
+	switch pick {
+	case FACETPICKTOP:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].NumberOfResults > candidates[j].NumberOfResults
+		})
+		if top < len(candidates) {
+			candidates = candidates[:top]
+		}
+	case FACETPICKRANDOM:
+	default:
+		total := 0
+		for _, value := range candidates {
+			total += value.NumberOfResults
+		}
+		if total > 0 {
+			roll := rand.Intn(total)
+			for _, value := range candidates {
+				roll -= value.NumberOfResults
+				if roll < 0 {
+					return value.Value, nil
+				}
+			}
+		}
+	}
+	return candidates[rand.Intn(len(candidates))].Value, nil
+}
@@ -0,0 +1,48 @@
+package scenariolib_test
+
+import (
+	"testing"
+
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestFacetStateExpression(t *testing.T) {
+	state := &scenariolib.FacetState{}
+	equals(t, "", state.Expression())
+
+	assert(t, state.Select("@objecttype", "Message"), "Expected Message to be selected.")
+	equals(t, `@objecttype=="Message"`, state.Expression())
+
+	assert(t, state.Select("@objecttype", "Case"), "Expected Case to be selected.")
+	assert(t, state.Select("@source", "Forum"), "Expected Forum to be selected.")
+	assert(t, !state.Select("@source", "Forum"), "Expected Forum to already be selected.")
+	equals(t, `@objecttype==("Message","Case") @source=="Forum"`, state.Expression())
+}
+
+func TestFacetStateDeselect(t *testing.T) {
+	state := &scenariolib.FacetState{}
+	state.Select("@objecttype", "Message")
+	state.Select("@objecttype", "Case")
+	state.Select("@source", "Forum")
+
+	assert(t, !state.Deselect("@source", "Blog"), "Expected Blog to not be selected.")
+	assert(t, state.Deselect("@objecttype", "Message"), "Expected Message to be deselected.")
+	equals(t, []string{"Case"}, state.Values("@objecttype"))
+
+	assert(t, state.Deselect("@source", "Forum"), "Expected Forum to be deselected.")
+	equals(t, `@objecttype=="Case"`, state.Expression())
+
+	state.Clear()
+	equals(t, "", state.Expression())
+	equals(t, 0, len(state.Values("@objecttype")))
+}
+
+func TestFacetStateRangeExpression(t *testing.T) {
+	state := &scenariolib.FacetState{}
+	state.SelectRange("@size", "0..100")
+	equals(t, `@size==0..100`, state.Expression())
+
+	state.SelectRange("@size", "100..1000")
+	state.Select("@objecttype", "Message")
+	equals(t, `@size==(0..100,100..1000) @objecttype=="Message"`, state.Expression())
+}
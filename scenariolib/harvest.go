@@ -0,0 +1,163 @@
+package scenariolib
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// DEFAULTHARVESTRESULTS The number of documents sampled to harvest queries when not specified.
+	DEFAULTHARVESTRESULTS int = 100
+	// DEFAULTHARVESTVALUES The number of values harvested from each field when not specified.
+	DEFAULTHARVESTVALUES int = 20
+	// DEFAULTHARVESTMAXNGRAM The number of words of the longest n-grams of the titles when not specified.
+	DEFAULTHARVESTMAXNGRAM int = 3
+	// DEFAULTHARVESTMAXQUERIES The maximum number of queries harvested when not specified.
+	DEFAULTHARVESTMAXQUERIES int = 500
+)
+
+// Harvest Settings of the queries harvested from the index. Documents are sampled with a blank
+// query, the n-grams of their titles and the values of the fields become good queries, and the
+// same terms with scrambled letters become bad queries.
+// RefreshMinutes The time between two harvests, the queries are only harvested at startup when 0
+type Harvest struct {
+	Enabled               bool     `json:"enabled"`
+	Fields                []string `json:"fields,omitempty"`
+	NumberOfResults       int      `json:"numberOfResults,omitempty"`
+	MaximumNumberOfValues int      `json:"maximumNumberOfValues,omitempty"`
+	MinNgram              int      `json:"minNgram,omitempty"`
+	MaxNgram              int      `json:"maxNgram,omitempty"`
+	MaxQueries            int      `json:"maxQueries,omitempty"`
+	RefreshMinutes        int      `json:"refreshMinutes,omitempty"`
+}
+
+// IsValid Validate the settings of the harvest.
+func (harvest *Harvest) IsValid() (bool, string) {
+	if harvest.NumberOfResults < 0 || harvest.MaximumNumberOfValues < 0 || harvest.MaxQueries < 0 || harvest.RefreshMinutes < 0 {
+		return false, "The numbers of the harvest must be positive integers."
+	}
+	if harvest.MinNgram < 0 || harvest.MaxNgram < 0 || (harvest.MaxNgram > 0 && harvest.MinNgram > harvest.MaxNgram) {
+		return false, "The minNgram of the harvest must be positive and smaller than maxNgram."
+	}
+	return true, ""
+}
+
+// needsRefresh Returns true if the queries harvested at the given time are outdated.
+func (harvest *Harvest) needsRefresh(lastHarvest time.Time) bool {
+	return harvest.Enabled && harvest.RefreshMinutes > 0 && time.Since(lastHarvest) >= time.Duration(harvest.RefreshMinutes)*time.Minute
+}
+
+// HarvestQueries Sample the index to replace the harvested good and bad queries of the config.
+func (c *Config) HarvestQueries(client search.Client) error {
+	harvest := c.Harvest
+	q := search.Query{
+		Q:               "",
+		NumberOfResults: harvest.NumberOfResults,
+		CQ:              c.GlobalFilter,
+		AQ:              c.QueryDefaults.AQ,
+		Pipeline:        c.Pipeline,
+		SearchHub:       c.SearchHub,
+	}
+	if q.NumberOfResults == 0 {
+		q.NumberOfResults = DEFAULTHARVESTRESULTS
+	}
+	maximumNumberOfValues := harvest.MaximumNumberOfValues
+	if maximumNumberOfValues == 0 {
+		maximumNumberOfValues = DEFAULTHARVESTVALUES
+	}
+	for _, field := range harvest.Fields {
+		q.GroupByRequests = append(q.GroupByRequests, &search.GroupByRequest{
+			Field:                 field,
+			MaximumNumberOfValues: maximumNumberOfValues,
+			SortCriteria:          "occurrences",
+		})
+	}
+
+	resp, err := client.Query(q)
+	if err != nil {
+		return err
+	}
+
+	maxQueries := harvest.MaxQueries
+	if maxQueries == 0 {
+		maxQueries = DEFAULTHARVESTMAXQUERIES
+	}
+	seen := make(map[string]bool)
+	good := []string{}
+	add := func(query string) {
+		query = strings.TrimSpace(query)
+		if query != "" && !seen[query] && len(good) < maxQueries {
+			seen[query] = true
+			good = append(good, query)
+		}
+	}
+	for _, field := range harvest.Fields {
+		for _, value := range groupByResultValues(resp, field) {
+			add(value.Value)
+		}
+	}
+	for _, result := range resp.Results {
+		for _, ngram := range harvest.titleNgrams(result.Title) {
+			add(ngram)
+		}
+	}
+
+	bad := []string{}
+	for _, query := range good {
+		if scrambled := scrambleTerms(query); scrambled != query && !seen[scrambled] {
+			bad = append(bad, scrambled)
+		}
+	}
+
+	Info.Printf("Harvested %d good queries and %d bad queries from the index", len(good), len(bad))
+	c.HarvestedGoodQueries = good
+	c.HarvestedBadQueries = bad
+	return nil
+}
+
+// titleNgrams Returns the sequences of consecutive words of a title, lower cased.
+func (harvest *Harvest) titleNgrams(title string) []string {
+	minNgram, maxNgram := harvest.MinNgram, harvest.MaxNgram
+	if minNgram == 0 {
+		minNgram = 1
+	}
+	if maxNgram == 0 {
+		maxNgram = DEFAULTHARVESTMAXNGRAM
+	}
+
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		// Single letters and digits are not worth searching for.
+		if len([]rune(word)) > 1 {
+			words = append(words, word)
+		}
+	}
+
+	ngrams := []string{}
+	for n := maxNgram; n >= minNgram; n-- {
+		for i := 0; i+n <= len(words); i++ {
+			ngrams = append(ngrams, strings.Join(words[i:i+n], " "))
+		}
+	}
+	return ngrams
+}
+
+// scrambleTerms Returns the query with the letters of each of its terms shuffled.
+func scrambleTerms(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		letters := []rune(term)
+		scrambled := make([]rune, len(letters))
+		for j, k := range rand.Perm(len(letters)) {
+			scrambled[j] = letters[k]
+		}
+		terms[i] = string(scrambled)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,74 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestHarvestValid(t *testing.T) {
+	var testHarvests = []scenariolib.Harvest{
+		{NumberOfResults: -1},
+		{MinNgram: 3, MaxNgram: 2},
+		{RefreshMinutes: -60},
+	}
+
+	for _, harvest := range testHarvests {
+		valid, _ := harvest.IsValid()
+		assert(t, !valid, "Expected harvest %v to be invalid.", harvest)
+	}
+}
+
+func TestHarvestQueries(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// The index has two documents and the values of their @author field.
+	queries := []*search.Query{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		query := &search.Query{}
+		json.NewDecoder(req.Body).Decode(query)
+		queries = append(queries, query)
+		rw.Write([]byte(`{"totalCount": 2,
+			"results": [{"title": "VPN Setup: Windows"}, {"title": "Reset a password"}],
+			"groupByResults": [{"field": "author", "values": [{"value": "Jane Doe", "numberOfResults": 2}]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := search.NewClient(search.Config{Token: "bot.searchToken", Endpoint: server.URL + defaults.SEARCH_REST_PATH})
+	ok(t, err)
+
+	conf := &scenariolib.Config{
+		GoodQueries:  []string{"rocky"},
+		GlobalFilter: "@source==Docs",
+		Harvest:      scenariolib.Harvest{Enabled: true, Fields: []string{"@author"}, MaxNgram: 2, NumberOfResults: 50},
+	}
+	ok(t, conf.HarvestQueries(client))
+
+	equals(t, 1, len(queries))
+	equals(t, "", queries[0].Q)
+	equals(t, "@source==Docs", queries[0].CQ)
+	equals(t, 50, queries[0].NumberOfResults)
+	equals(t, "@author", queries[0].GroupByRequests[0].Field)
+
+	// The single letter "a" is not a term of the n-grams.
+	equals(t, []string{"Jane Doe", "vpn setup", "setup windows", "vpn", "setup", "windows", "reset password", "reset", "password"}, conf.HarvestedGoodQueries)
+	equals(t, []string{"rocky"}, conf.GoodQueries)
+
+	// The bad queries are the same terms with their letters scrambled.
+	assert(t, len(conf.HarvestedBadQueries) > 0, "Expected harvested bad queries.")
+	for _, bad := range conf.HarvestedBadQueries {
+		found := false
+		for _, good := range conf.HarvestedGoodQueries {
+			if sortedLetters(good) == sortedLetters(bad) {
+				found = true
+			}
+		}
+		assert(t, found, "Expected %s to be a scrambled good query.", bad)
+	}
+}
@@ -0,0 +1,231 @@
+package scenariolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// DEFAULTMAXGRADE The grade of the most relevant documents when not specified.
+	DEFAULTMAXGRADE int = 3
+	// DEFAULTUNJUDGEDPROBABILITY The probability to click on an examined document without a grade.
+	DEFAULTUNJUDGEDPROBABILITY float64 = 0.05
+)
+
+// Judgments The graded relevance of documents for queries, used to click on the results
+// like a user looking for the relevant documents.
+// MaxGrade            The grade of the most relevant documents, the grades go from 0 to MaxGrade
+// UnjudgedProbability The probability to click on an examined document that is not judged
+// Queries             The judged queries and their documents
+type Judgments struct {
+	MaxGrade            int            `json:"maxGrade,omitempty"`
+	UnjudgedProbability *float64       `json:"unjudgedProbability,omitempty"`
+	Queries             []*JudgedQuery `json:"queries"`
+}
+
+// JudgedQuery The documents judged for a query, or for the queries matching a pattern.
+type JudgedQuery struct {
+	Query        string            `json:"query,omitempty"`
+	QueryPattern string            `json:"queryPattern,omitempty"`
+	Documents    []*JudgedDocument `json:"documents"`
+	queryRegex   *regexp.Regexp
+}
+
+// JudgedDocument The grade of the documents matching a urihash, a title or a field pattern.
+type JudgedDocument struct {
+	Grade        int    `json:"grade"`
+	URIHash      string `json:"urihash,omitempty"`
+	Title        string `json:"title,omitempty"`
+	MatchField   string `json:"matchField,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+	regexMatch   *regexp.Regexp
+}
+
+// NewJudgmentsFromPath Read the judgments from a JSON file path, or from an URL
+// when the path starts with http:// or https://.
+func NewJudgmentsFromPath(path string) (*Judgments, error) {
+	var content []byte
+	var err error
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		var resp *http.Response
+		if resp, err = http.Get(path); err != nil {
+			return nil, fmt.Errorf("Cannot read judgments file : %v", err)
+		}
+		defer resp.Body.Close()
+		content, err = ioutil.ReadAll(resp.Body)
+	} else {
+		content, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read judgments file : %v", err)
+	}
+
+	judgments := &Judgments{}
+	if err = json.Unmarshal(content, judgments); err != nil {
+		return nil, fmt.Errorf("Cannot parse judgments file : %v", err)
+	}
+	return judgments, nil
+}
+
+// IsValid Validate the grades and the matchers of the judgments, and compile their patterns.
+func (judgments *Judgments) IsValid() (bool, string) {
+	if judgments.MaxGrade < 0 {
+		return false, "maxGrade must be a positive integer."
+	}
+	if p := judgments.UnjudgedProbability; p != nil && (*p < 0 || *p > 1) {
+		return false, "unjudgedProbability must be between 0 and 1."
+	}
+	var err error
+	for _, judged := range judgments.Queries {
+		if (judged.Query == "") == (judged.QueryPattern == "") {
+			return false, "A judged query needs either a query or a queryPattern."
+		}
+		if judged.QueryPattern != "" {
+			if judged.queryRegex, err = regexp.Compile(judged.QueryPattern); err != nil {
+				return false, "Failed to compile query pattern : " + err.Error()
+			}
+		}
+		for _, document := range judged.Documents {
+			if document.Grade < 0 || document.Grade > judgments.maxGrade() {
+				return false, fmt.Sprintf("The grades of the documents must be between 0 and %d.", judgments.maxGrade())
+			}
+			matchers := 0
+			for _, matcher := range []string{document.URIHash, document.Title, document.MatchPattern} {
+				if matcher != "" {
+					matchers++
+				}
+			}
+			if matchers != 1 || (document.MatchPattern == "") != (document.MatchField == "") {
+				return false, "A judged document needs either a urihash, a title or both matchField and matchPattern."
+			}
+			if document.MatchPattern != "" {
+				if document.regexMatch, err = regexp.Compile(document.MatchPattern); err != nil {
+					return false, "Failed to compile regex pattern : " + err.Error()
+				}
+			}
+		}
+	}
+	return true, ""
+}
+
+func (judgments *Judgments) maxGrade() int {
+	if judgments.MaxGrade == 0 {
+		return DEFAULTMAXGRADE
+	}
+	return judgments.MaxGrade
+}
+
+func (judgments *Judgments) unjudgedProbability() float64 {
+	if judgments.UnjudgedProbability == nil {
+		return DEFAULTUNJUDGEDPROBABILITY
+	}
+	return *judgments.UnjudgedProbability
+}
+
+// Find Returns the judgments of the query, a query judged by its text before one
+// judged by a pattern. Returns nil if the query is not judged.
+func (judgments *Judgments) Find(query string) *JudgedQuery {
+	for _, judged := range judgments.Queries {
+		if judged.Query != "" && strings.EqualFold(judged.Query, query) {
+			return judged
+		}
+	}
+	for _, judged := range judgments.Queries {
+		if judged.queryRegex != nil && judged.queryRegex.MatchString(query) {
+			return judged
+		}
+	}
+	return nil
+}
+
+// queries Returns the judged queries, and the candidates matching a judged pattern.
+func (judgments *Judgments) queries(candidates []string) []string {
+	queries := []string{}
+	for _, judged := range judgments.Queries {
+		if judged.Query != "" {
+			queries = append(queries, judged.Query)
+		}
+	}
+	for _, candidate := range candidates {
+		for _, judged := range judgments.Queries {
+			if judged.queryRegex != nil && judged.queryRegex.MatchString(candidate) {
+				queries = append(queries, candidate)
+				break
+			}
+		}
+	}
+	return queries
+}
+
+// Grade Returns the grade of the first judged document matching the result.
+func (judged *JudgedQuery) Grade(result search.Result) (grade int, ok bool) {
+	if document := judged.document(result); document != nil {
+		return document.Grade, true
+	}
+	return 0, false
+}
+
+// document Returns the first judged document matching the result, nil if none matches.
+func (judged *JudgedQuery) document(result search.Result) *JudgedDocument {
+	for _, document := range judged.Documents {
+		if document.matches(result) {
+			return document
+		}
+	}
+	return nil
+}
+
+func (document *JudgedDocument) matches(result search.Result) bool {
+	switch {
+	case document.URIHash != "":
+		urihash, ok := getFieldValueFromRaw(result.Raw, "urihash").(string)
+		return ok && urihash == document.URIHash
+	case document.Title != "":
+		return strings.Contains(strings.ToLower(result.Title), strings.ToLower(document.Title))
+	default:
+		value, ok := result.Raw[document.MatchField].(string)
+		return ok && document.regexMatch.MatchString(value)
+	}
+}
+
+// ClickProbabilities Returns the probability to click on each examined result of the query,
+// (2^grade - 1) / (2^maxGrade - 1) for the judged documents.
+func (judgments *Judgments) ClickProbabilities(query string, results []search.Result) []float64 {
+	judged := judgments.Find(query)
+	probabilities := make([]float64, len(results))
+	for i, result := range results {
+		probabilities[i] = judgments.unjudgedProbability()
+		if judged == nil {
+			continue
+		}
+		if grade, ok := judged.Grade(result); ok {
+			probabilities[i] = (math.Pow(2, float64(grade)) - 1) / (math.Pow(2, float64(judgments.maxGrade())) - 1)
+		}
+	}
+	return probabilities
+}
+
+// loadJudgments Read the judgments file of the config, then validate the judgments.
+func (c *Config) loadJudgments() error {
+	if c.JudgmentsFile != "" {
+		judgments, err := NewJudgmentsFromPath(c.JudgmentsFile)
+		if err != nil {
+			return err
+		}
+		c.Judgments = judgments
+	}
+	if c.Judgments == nil {
+		return nil
+	}
+	if valid, message := c.Judgments.IsValid(); !valid {
+		return fmt.Errorf("Invalid judgments : %s", message)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestJudgmentsInvalid(t *testing.T) {
+	var testJudgments = []string{
+		`{"maxGrade": -1}`,
+		`{"unjudgedProbability": 1.5}`,
+		`{"queries": [{"documents": []}]}`,
+		`{"queries": [{"query": "a", "queryPattern": "a"}]}`,
+		`{"queries": [{"queryPattern": "("}]}`,
+		`{"queries": [{"query": "a", "documents": [{"grade": 4, "urihash": "hash"}]}]}`,
+		`{"queries": [{"query": "a", "documents": [{"grade": 1}]}]}`,
+		`{"queries": [{"query": "a", "documents": [{"grade": 1, "urihash": "hash", "title": "title"}]}]}`,
+		`{"queries": [{"query": "a", "documents": [{"grade": 1, "matchPattern": "a"}]}]}`,
+	}
+
+	for _, test := range testJudgments {
+		judgments := &scenariolib.Judgments{}
+		ok(t, json.Unmarshal([]byte(test), judgments))
+		valid, _ := judgments.IsValid()
+		assert(t, !valid, "Expected judgments %s to be invalid.", test)
+	}
+}
+
+func TestJudgmentsClickProbabilities(t *testing.T) {
+	judgments := &scenariolib.Judgments{}
+	ok(t, json.Unmarshal([]byte(`{"queries": [
+		{"query": "ghostbusters", "documents": [
+			{"grade": 3, "urihash": "hash1"},
+			{"grade": 1, "title": "Sequel"},
+			{"grade": 0, "matchField": "author", "matchPattern": "^Reit"}
+		]},
+		{"queryPattern": "^ghost", "documents": [{"grade": 2, "urihash": "hash1"}]}
+	]}`), judgments))
+	valid, message := judgments.IsValid()
+	assert(t, valid, "Expected judgments to be valid, was false with error: %s", message)
+
+	results := []search.Result{
+		{Title: "Ghostbusters", Raw: map[string]interface{}{"urihash": "hash1"}},
+		{Title: "Ghostbusters, the sequel", Raw: map[string]interface{}{"urihash": "hash2"}},
+		{Title: "Other", Raw: map[string]interface{}{"urihash": "hash3", "author": "Reitman"}},
+		{Title: "Unjudged", Raw: map[string]interface{}{"urihash": "hash4"}},
+	}
+	equals(t, []float64{1, 1.0 / 7, 0, 0.05}, judgments.ClickProbabilities("Ghostbusters", results))
+
+	// The query matching the pattern has the grades of the pattern.
+	equals(t, []float64{3.0 / 7, 0.05, 0.05, 0.05}, judgments.ClickProbabilities("ghost in the shell", results))
+
+	// A query without judgments only has unjudged documents.
+	assert(t, judgments.Find("shell") == nil, "Expected no judgments for the query.")
+	equals(t, []float64{0.05, 0.05, 0.05, 0.05}, judgments.ClickProbabilities("shell", results))
+}
+
+func TestConfigJudgmentsFile(t *testing.T) {
+	judgmentsPath := writeTestConfig(t, `{"maxGrade": 1, "queries": [{"query": "a", "documents": [{"grade": 1, "urihash": "hash"}]}]}`)
+	defer os.Remove(judgmentsPath)
+	path := writeTestConfig(t, `{"judgmentsFile": "`+judgmentsPath+`", "scenarios": []}`)
+	defer os.Remove(path)
+
+	conf, err := scenariolib.NewConfigFromPath(path)
+	ok(t, err)
+	equals(t, 1, conf.Judgments.MaxGrade)
+	assert(t, conf.Judgments.Find("a") != nil, "Expected the judgments of the file.")
+
+	invalidPath := writeTestConfig(t, `{"judgments": {"queries": [{"query": "a", "documents": [{"grade": 5, "urihash": "hash"}]}]}, "scenarios": []}`)
+	defer os.Remove(invalidPath)
+	_, err = scenariolib.NewConfigFromPath(invalidPath)
+	notok(t, err)
+}
+
+func TestJudgedSearchEvent(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// Keep the queries and the position of every click sent to the analytics.
+	queries := []string{}
+	positions := []float64{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch req.URL.Path {
+		case defaults.SEARCH_REST_PATH:
+			queries = append(queries, body["q"].(string))
+			rw.Write([]byte(`{"totalCount": 4, "results": [
+				{"uri": "uri1", "raw": {"urihash": "hash1"}},
+				{"uri": "uri2", "raw": {"urihash": "hash2"}},
+				{"uri": "uri3", "raw": {"urihash": "hash3"}},
+				{"uri": "uri4", "raw": {"urihash": "hash4"}}
+			]}`))
+			return
+		case defaults.ANALYTICS_REST_PATH + "click/":
+			positions = append(positions, body["documentPosition"].(float64))
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.GoodQueries = []string{"ghost in the shell", "other"}
+	unjudged := 0.0
+	conf.Judgments = &scenariolib.Judgments{UnjudgedProbability: &unjudged, Queries: []*scenariolib.JudgedQuery{
+		{QueryPattern: "^ghost", Documents: []*scenariolib.JudgedDocument{
+			{Grade: 3, URIHash: "hash2"},
+			{Grade: 0, URIHash: "hash3"},
+			{Grade: 3, URIHash: "hash4"},
+		}},
+	}}
+	valid, message := conf.Judgments.IsValid()
+	assert(t, valid, "Expected judgments to be valid, was false with error: %s", message)
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.WaitBetweenActions = false
+
+	// Only the good query matching the judged pattern is searched, only the relevant documents are clicked.
+	event := &scenariolib.JudgedSearchEvent{ClickModel: &scenariolib.ClickModel{Type: "dependent", Continuation: []float64{1}}}
+	for i := 0; i < 5; i++ {
+		positions = positions[:0]
+		ok(t, event.Execute(v))
+		equals(t, "ghost in the shell", queries[len(queries)-1])
+		equals(t, []float64{2, 4}, positions)
+	}
+
+	// Without judgments, the event cannot pick a query.
+	conf.Judgments = nil
+	err = event.Execute(v)
+	assert(t, err != nil && strings.Contains(err.Error(), "judgments"), "Expected an error without judgments.")
+}
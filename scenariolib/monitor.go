@@ -0,0 +1,321 @@
+package scenariolib
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+// DEFAULTMONITORDEPTH The number of results searched for the target document when not specified.
+const DEFAULTMONITORDEPTH int = 50
+
+// rankObservationsHeader The columns of the CSV file of the rank observations.
+var rankObservationsHeader = []string{"time", "scenario", "pipeline", "query", "target", "rank", "numberOfResults"}
+
+// MonitorTarget The document a SearchAndClick event of a scenario looks for with its query.
+type MonitorTarget struct {
+	Scenario string
+	Query    string
+	Target   string
+	config   *Config
+	event    *SearchAndClickEvent
+}
+
+// RankObservation The rank of the target document of a query at a time, 1 based,
+// 0 when the document is not in the results searched.
+type RankObservation struct {
+	Time            time.Time
+	Scenario        string
+	Pipeline        string
+	Query           string
+	Target          string
+	Rank            int
+	NumberOfResults int
+}
+
+// RankTrend How the rank of the target document of a query changed over the observations.
+type RankTrend struct {
+	Query        string
+	Target       string
+	Pipeline     string
+	Observations int
+	First        int
+	Last         int
+	Best         int
+	Worst        int
+}
+
+// MonitorTargets Returns the targets of the SearchAndClick events of the scenarios, including
+// the ones in the states of markov scenarios. The random queries and the queries using visit
+// variables are skipped.
+func (c *Config) MonitorTargets() ([]*MonitorTarget, error) {
+	targets := []*MonitorTarget{}
+	seen := make(map[string]bool)
+	for _, scenario := range c.Scenarios {
+		events := append([]JSONEvent{}, scenario.Events...)
+		names := make([]string, 0, len(scenario.States))
+		for name := range scenario.States {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			state := scenario.States[name]
+			if state == nil || state.Event == nil {
+				continue
+			}
+			if state.Event.Type != INCLUDEEVENTTYPE {
+				events = append(events, *state.Event)
+				continue
+			}
+			include, err := parseInclude(state.Event)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, c.EventBlocks[include.Block]...)
+		}
+
+		scenarioConfig := c.ForScenario(scenario)
+		for _, event := range events {
+			if event.Type != "SearchAndClick" {
+				continue
+			}
+			searchClick := &SearchAndClickEvent{}
+			if err := json.Unmarshal(event.Arguments, searchClick); err != nil {
+				return nil, fmt.Errorf("Scenario %s : %v", scenario.Name, err)
+			}
+			if valid, message := searchClick.IsValid(); !valid {
+				return nil, fmt.Errorf("Scenario %s : %s", scenario.Name, message)
+			}
+			if searchClick.Query == "" {
+				Warning.Printf("Cannot monitor a SearchAndClick event of scenario %s, it searches for random queries", scenario.Name)
+				continue
+			}
+			if variableTemplate.MatchString(string(event.Arguments)) {
+				Warning.Printf("Cannot monitor the query %s of scenario %s, it uses visit variables", searchClick.Query, scenario.Name)
+				continue
+			}
+
+			target := searchClick.DocTitle
+			if pool := searchClick.pool(); len(pool) > 0 {
+				descriptions := []string{}
+				for _, clickTarget := range pool {
+					descriptions = append(descriptions, clickTarget.String())
+				}
+				target = strings.Join(descriptions, " | ")
+			} else if target == "" {
+				target = searchClick.MatchField + "=~" + searchClick.MatchPattern
+			}
+			key := scenarioConfig.Pipeline + "\x00" + searchClick.Query + "\x00" + target
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			targets = append(targets, &MonitorTarget{
+				Scenario: scenario.Name,
+				Query:    searchClick.Query,
+				Target:   target,
+				config:   scenarioConfig,
+				event:    searchClick,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// ObserveRanks Search for the query of each target, without sending analytics, and returns
+// the rank of the target document in the first depth results.
+func ObserveRanks(targets []*MonitorTarget, searchToken string, depth int) ([]RankObservation, error) {
+	if depth <= 0 {
+		depth = DEFAULTMONITORDEPTH
+	}
+	now := time.Now()
+	clients := make(map[string]search.Client)
+	observations := []RankObservation{}
+	for _, target := range targets {
+		conf := target.config
+		client, ok := clients[conf.SearchEndpoint]
+		if !ok {
+			var err error
+			if client, err = search.NewClient(search.Config{Token: searchToken, UserAgent: conf.RandomData.UserAgents[0], Endpoint: conf.SearchEndpoint}); err != nil {
+				return nil, err
+			}
+			clients[conf.SearchEndpoint] = client
+		}
+
+		resp, err := client.Query(target.query(depth))
+		if err != nil {
+			return nil, fmt.Errorf("Cannot search for %s : %v", target.Query, err)
+		}
+
+		// Find the document like the SearchAndClick event does, the best ranked target of a pool.
+		v := &Visit{LastResponse: resp}
+		rank := -1
+		if pool := target.event.pool(); len(pool) > 0 {
+			for _, clickTarget := range pool {
+				if found := v.FindDocumentRankByTarget(clickTarget); found >= 0 && (rank < 0 || found < rank) {
+					rank = found
+				}
+			}
+		} else {
+			rank = target.event.FindTargetRank(v)
+		}
+		Info.Printf("Target %s of query %s at rank %d", target.Target, target.Query, rank+1)
+		observations = append(observations, RankObservation{
+			Time:            now,
+			Scenario:        target.Scenario,
+			Pipeline:        conf.Pipeline,
+			Query:           target.Query,
+			Target:          target.Target,
+			Rank:            rank + 1,
+			NumberOfResults: resp.TotalCount,
+		})
+	}
+	return observations, nil
+}
+
+// query Returns the query sent by the SearchAndClick event at the start of a visit, built like
+// a visit and the event do, for the first depth results.
+func (target *MonitorTarget) query(depth int) search.Query {
+	v := &Visit{Config: target.config}
+	v.SetupGeneral()
+	v.LastQuery.CQ = target.config.GlobalFilter
+	v.SetContext(target.event.Context)
+	if target.event.CaseSearch {
+		v.LastQuery.AQ = caseSearchQuery(target.Query)
+	} else {
+		v.LastQuery.Q = target.Query
+	}
+	v.LastQuery.NumberOfResults = depth
+	return *v.LastQuery
+}
+
+// AppendRankObservations Append the observations to a CSV file, created with its header if needed.
+func AppendRankObservations(path string, observations []RankObservation) error {
+	info, err := os.Stat(path)
+	newFile := os.IsNotExist(err) || (err == nil && info.Size() == 0)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if newFile {
+		writer.Write(rankObservationsHeader)
+	}
+	for _, observation := range observations {
+		writer.Write([]string{
+			observation.Time.Format(time.RFC3339),
+			observation.Scenario,
+			observation.Pipeline,
+			observation.Query,
+			observation.Target,
+			strconv.Itoa(observation.Rank),
+			strconv.Itoa(observation.NumberOfResults),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReadRankObservations Read the observations saved in a CSV file.
+func ReadRankObservations(r io.Reader) ([]RankObservation, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	observations := []RankObservation{}
+	for i, record := range records {
+		if i == 0 && record[0] == rankObservationsHeader[0] {
+			continue
+		}
+		if len(record) != len(rankObservationsHeader) {
+			return nil, fmt.Errorf("Line %d has %d columns instead of %d", i+1, len(record), len(rankObservationsHeader))
+		}
+		observation := RankObservation{Scenario: record[1], Pipeline: record[2], Query: record[3], Target: record[4]}
+		if observation.Time, err = time.Parse(time.RFC3339, record[0]); err != nil {
+			return nil, fmt.Errorf("Line %d : %v", i+1, err)
+		}
+		if observation.Rank, err = strconv.Atoi(record[5]); err != nil {
+			return nil, fmt.Errorf("Line %d : %v", i+1, err)
+		}
+		if observation.NumberOfResults, err = strconv.Atoi(record[6]); err != nil {
+			return nil, fmt.Errorf("Line %d : %v", i+1, err)
+		}
+		observations = append(observations, observation)
+	}
+	return observations, nil
+}
+
+// RankTrends Returns the trend of each query, target and pipeline of the observations, in
+// the order of their first observation. The observations must be in chronological order.
+func RankTrends(observations []RankObservation) []*RankTrend {
+	trends := []*RankTrend{}
+	byKey := make(map[string]*RankTrend)
+	for _, observation := range observations {
+		key := observation.Pipeline + "\x00" + observation.Query + "\x00" + observation.Target
+		trend, ok := byKey[key]
+		if !ok {
+			trend = &RankTrend{Query: observation.Query, Target: observation.Target, Pipeline: observation.Pipeline, First: observation.Rank, Best: observation.Rank, Worst: observation.Rank}
+			byKey[key] = trend
+			trends = append(trends, trend)
+		}
+		trend.Observations++
+		trend.Last = observation.Rank
+		if betterRank(observation.Rank, trend.Best) {
+			trend.Best = observation.Rank
+		}
+		if betterRank(trend.Worst, observation.Rank) {
+			trend.Worst = observation.Rank
+		}
+	}
+	return trends
+}
+
+// betterRank Whether the rank a is better than b, a document not found (0) being the worst.
+func betterRank(a, b int) bool {
+	return a != 0 && (b == 0 || a < b)
+}
+
+// Summary Describes how the rank changed, such as "up 3", "down 2", "found", "lost" or "stable".
+func (trend *RankTrend) Summary() string {
+	switch {
+	case trend.First == trend.Last:
+		return "stable"
+	case trend.First == 0:
+		return "found"
+	case trend.Last == 0:
+		return "lost"
+	case trend.Last < trend.First:
+		return fmt.Sprintf("up %d", trend.First-trend.Last)
+	default:
+		return fmt.Sprintf("down %d", trend.Last-trend.First)
+	}
+}
+
+// WriteRankTrends Write the trends in aligned columns of text.
+func WriteRankTrends(w io.Writer, trends []*RankTrend) error {
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "query\ttarget\tpipeline\tobservations\tfirst\tlast\tbest\tworst\ttrend\t")
+	for _, trend := range trends {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t\n", trend.Query, trend.Target, trend.Pipeline, trend.Observations,
+			rankColumn(trend.First), rankColumn(trend.Last), rankColumn(trend.Best), rankColumn(trend.Worst), trend.Summary())
+	}
+	return writer.Flush()
+}
+
+func rankColumn(rank int) string {
+	if rank == 0 {
+		return "-"
+	}
+	return strconv.Itoa(rank)
+}
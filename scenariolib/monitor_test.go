@@ -0,0 +1,112 @@
+package scenariolib_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestMonitorRanks(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// The pipeline "ml" ranks Rocky first for balboa.
+	queries := []*search.Query{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		query := &search.Query{}
+		json.NewDecoder(req.Body).Decode(query)
+		queries = append(queries, query)
+		if query.Pipeline == "ml" {
+			rw.Write([]byte(`{"totalCount": 2, "results": [{"title": "Rocky", "raw": {"author": "Stallone"}}, {"title": "Creed"}]}`))
+			return
+		}
+		rw.Write([]byte(`{"totalCount": 2, "results": [{"title": "Creed"}, {"title": "Rocky", "raw": {"author": "Stallone"}}]}`))
+	}))
+	defer server.Close()
+
+	path := writeTestConfig(t, `{
+		"searchendpoint": "`+server.URL+defaults.SEARCH_REST_PATH+`",
+		"scenarios": [
+			{"name": "default", "weight": 1, "events": [
+				{"type": "SearchAndClick", "arguments": {"queryText": "balboa", "docClickTitle": "rocky", "probability": 1}},
+				{"type": "SearchAndClick", "arguments": {"queryText": "${query}", "docClickTitle": "rocky", "probability": 1}},
+				{"type": "SearchAndClick", "arguments": {"queryText": "", "docClickTitle": "rocky", "probability": 1}},
+				{"type": "SearchAndClick", "arguments": {"queryText": "boxer", "docClickTitle": "rocky", "probability": 1, "caseSearch": true, "inputTitle": "Boxing"}},
+				{"type": "Search", "arguments": {"queryText": "creed"}}
+			]},
+			{"name": "ml", "weight": 1, "pipeline": "ml", "kind": "markov", "startState": "search", "states": {
+				"search": {"event": {"type": "SearchAndClick", "arguments": {"queryText": "balboa", "matchField": "author", "matchPattern": "^Stal", "probability": 1}}}
+			}}
+		]
+	}`)
+	defer os.Remove(path)
+	conf, err := scenariolib.NewConfigFromPath(path)
+	ok(t, err)
+
+	// The random query and the query using a visit variable cannot be monitored.
+	targets, err := conf.MonitorTargets()
+	ok(t, err)
+	equals(t, 3, len(targets))
+	equals(t, "rocky", targets[0].Target)
+	equals(t, "boxer", targets[1].Query)
+	equals(t, "author=~^Stal", targets[2].Target)
+
+	observations, err := scenariolib.ObserveRanks(targets, "bot.searchToken", 10)
+	ok(t, err)
+	equals(t, 3, len(queries))
+	equals(t, "balboa", queries[0].Q)
+	equals(t, 10, queries[0].NumberOfResults)
+	equals(t, "ml", queries[2].Pipeline)
+	equals(t, 2, observations[0].Rank)
+	equals(t, 1, observations[2].Rank)
+	equals(t, "ml", observations[2].Pipeline)
+
+	// The case search looks for its keywords in the advanced query, like the event does.
+	equals(t, "", queries[1].Q)
+	equals(t, "($some(keywords: 'boxer', match: 1, maximum: 300))", queries[1].AQ)
+}
+
+func TestRankObservationsFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "uabot-ranks")
+	ok(t, err)
+	ok(t, file.Close())
+	defer os.Remove(file.Name())
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	observe := func(hours int, query string, rank int) scenariolib.RankObservation {
+		return scenariolib.RankObservation{Time: start.Add(time.Duration(hours) * time.Hour), Scenario: "s", Query: query, Target: "rocky", Rank: rank, NumberOfResults: 10}
+	}
+	ok(t, scenariolib.AppendRankObservations(file.Name(), []scenariolib.RankObservation{observe(0, "balboa", 5), observe(0, "italian, stallion", 0)}))
+	ok(t, scenariolib.AppendRankObservations(file.Name(), []scenariolib.RankObservation{observe(1, "balboa", 2), observe(1, "italian, stallion", 3)}))
+	ok(t, scenariolib.AppendRankObservations(file.Name(), []scenariolib.RankObservation{observe(2, "balboa", 7), observe(2, "italian, stallion", 3)}))
+
+	content, err := os.Open(file.Name())
+	ok(t, err)
+	defer content.Close()
+	observations, err := scenariolib.ReadRankObservations(content)
+	ok(t, err)
+	equals(t, 6, len(observations))
+	equals(t, observe(1, "italian, stallion", 3), observations[3])
+
+	trends := scenariolib.RankTrends(observations)
+	equals(t, 2, len(trends))
+	equals(t, scenariolib.RankTrend{Query: "balboa", Target: "rocky", Observations: 3, First: 5, Last: 7, Best: 2, Worst: 7}, *trends[0])
+	equals(t, "down 2", trends[0].Summary())
+	equals(t, 0, trends[1].Worst)
+	equals(t, "found", trends[1].Summary())
+
+	var summary bytes.Buffer
+	ok(t, scenariolib.WriteRankTrends(&summary, trends))
+	lines := strings.Split(strings.TrimSpace(summary.String()), "\n")
+	equals(t, 3, len(lines))
+	assert(t, strings.HasSuffix(strings.TrimSpace(lines[1]), "down 2"), "Unexpected trend %s", lines[1])
+}
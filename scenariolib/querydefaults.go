@@ -0,0 +1,88 @@
+package scenariolib
+
+import (
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// DEFAULTNUMBEROFRESULTS The number of results per page when not specified.
+	DEFAULTNUMBEROFRESULTS int = 20
+	// DEFAULTTAB The tab of the queries when not specified.
+	DEFAULTTAB string = "All"
+)
+
+// QueryDefaults The parameters of the queries of a visit, replacing the customer specific setups.
+type QueryDefaults struct {
+	// NumberOfResults The number of results per page.
+	NumberOfResults int `json:"numberOfResults,omitempty"`
+
+	// SortCriteria The sort of the results (relevancy, date ascending, @field descending, etc.).
+	SortCriteria string `json:"sortCriteria,omitempty"`
+
+	// FieldsToInclude The fields returned with the results, all of them if empty.
+	FieldsToInclude []string `json:"fieldsToInclude,omitempty"`
+
+	// EnableDidYouMean Ask for query corrections.
+	EnableDidYouMean *bool `json:"enableDidYouMean,omitempty"`
+
+	// GroupByRequests The groupBy requests of the facets of the search page.
+	GroupByRequests []*search.GroupByRequest `json:"groupBy,omitempty"`
+
+	// AQ An advanced query expression sent with every query, such as a filter of the search page.
+	AQ string `json:"aq,omitempty"`
+
+	// Locale The locale of the queries.
+	Locale string `json:"locale,omitempty"`
+
+	// Timezone The timezone of the queries.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// merge Returns the query defaults with the values set in the overrides replacing them.
+func (defaults QueryDefaults) merge(overrides *QueryDefaults) QueryDefaults {
+	if overrides == nil {
+		return defaults
+	}
+	if overrides.NumberOfResults > 0 {
+		defaults.NumberOfResults = overrides.NumberOfResults
+	}
+	if overrides.SortCriteria != "" {
+		defaults.SortCriteria = overrides.SortCriteria
+	}
+	if overrides.FieldsToInclude != nil {
+		defaults.FieldsToInclude = overrides.FieldsToInclude
+	}
+	if overrides.EnableDidYouMean != nil {
+		defaults.EnableDidYouMean = overrides.EnableDidYouMean
+	}
+	if overrides.GroupByRequests != nil {
+		defaults.GroupByRequests = overrides.GroupByRequests
+	}
+	if overrides.AQ != "" {
+		defaults.AQ = overrides.AQ
+	}
+	if overrides.Locale != "" {
+		defaults.Locale = overrides.Locale
+	}
+	if overrides.Timezone != "" {
+		defaults.Timezone = overrides.Timezone
+	}
+	return defaults
+}
+
+// apply Set the query defaults on a query.
+func (defaults QueryDefaults) apply(q *search.Query) {
+	if defaults.NumberOfResults > 0 {
+		q.NumberOfResults = defaults.NumberOfResults
+	}
+	q.SortCriteria = defaults.SortCriteria
+	q.FieldsToInclude = defaults.FieldsToInclude
+	if defaults.EnableDidYouMean != nil {
+		q.EnableDidYouMean = *defaults.EnableDidYouMean
+	}
+	// Copy the requests so events adding groupBy requests don't modify the config.
+	q.GroupByRequests = append(q.GroupByRequests, defaults.GroupByRequests...)
+	q.AQ = defaults.AQ
+	q.Locale = defaults.Locale
+	q.Timezone = defaults.Timezone
+}
@@ -0,0 +1,63 @@
+package scenariolib_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestQueryDefaults(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	path := writeTestConfig(t, `{
+		"queryDefaults": {
+			"numberOfResults": 10,
+			"sortCriteria": "date descending",
+			"fieldsToInclude": ["title", "urihash"],
+			"enableDidYouMean": true,
+			"groupBy": [{"field": "@objecttype", "maximumNumberOfValues": 6}],
+			"aq": "NOT @objecttype==(User,Case)",
+			"locale": "fr-CA",
+			"timezone": "America/Montreal"
+		},
+		"scenarios": [{
+			"name": "override",
+			"weight": 1,
+			"queryDefaults": {"numberOfResults": 50, "enableDidYouMean": false},
+			"events": []
+		}]
+	}`)
+	defer os.Remove(path)
+
+	conf, err := scenariolib.NewConfigFromPath(path)
+	ok(t, err)
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	equals(t, 10, v.LastQuery.NumberOfResults)
+	equals(t, "date descending", v.LastQuery.SortCriteria)
+	equals(t, []string{"title", "urihash"}, v.LastQuery.FieldsToInclude)
+	assert(t, v.LastQuery.EnableDidYouMean, "Expected EnableDidYouMean to be true.")
+	equals(t, 1, len(v.LastQuery.GroupByRequests))
+	equals(t, "@objecttype", v.LastQuery.GroupByRequests[0].Field)
+	equals(t, "NOT @objecttype==(User,Case)", v.LastQuery.AQ)
+	equals(t, "fr-CA", v.LastQuery.Locale)
+	equals(t, "America/Montreal", v.LastQuery.Timezone)
+	equals(t, "All", v.LastQuery.Tab)
+
+	// Adding a groupBy request to the visit query does not modify the config.
+	v.LastQuery.GroupByRequests = append(v.LastQuery.GroupByRequests, &search.GroupByRequest{Field: "@author"})
+	equals(t, 1, len(conf.QueryDefaults.GroupByRequests))
+
+	v, err = scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf.ForScenario(conf.Scenarios[0]))
+	ok(t, err)
+	v.SetupGeneral()
+
+	equals(t, 50, v.LastQuery.NumberOfResults)
+	assert(t, !v.LastQuery.EnableDidYouMean, "Expected EnableDidYouMean to be overridden to false.")
+	equals(t, "date descending", v.LastQuery.SortCriteria)
+}
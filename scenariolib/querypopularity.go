@@ -0,0 +1,62 @@
+package scenariolib
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// QueryPopularity How often each query of a pool is picked. Explicit weights have precedence
+// over the Zipf distribution, where the query at rank r in its pool has a weight of 1/r^ZipfExponent.
+// Queries are picked uniformly when neither is specified.
+type QueryPopularity struct {
+	ZipfExponent float64            `json:"zipfExponent,omitempty"`
+	Weights      map[string]float64 `json:"weights,omitempty"`
+}
+
+// IsValid Validate the exponent and the weights of the popularity.
+func (popularity QueryPopularity) IsValid() (bool, string) {
+	if popularity.ZipfExponent < 0 {
+		return false, "The zipfExponent of the queryPopularity must be positive."
+	}
+	for query, weight := range popularity.Weights {
+		if weight < 0 {
+			return false, fmt.Sprintf("The weight of query %s in the queryPopularity must be positive.", query)
+		}
+	}
+	return true, ""
+}
+
+// weight Returns the weight of the query at the given rank (0 based) of its pool.
+func (popularity QueryPopularity) weight(query string, rank int) float64 {
+	if weight, ok := popularity.Weights[query]; ok {
+		return weight
+	}
+	if popularity.ZipfExponent > 0 {
+		return 1 / math.Pow(float64(rank+1), popularity.ZipfExponent)
+	}
+	return 1
+}
+
+// pick Randomize a query of the pool following the popularity.
+func (popularity QueryPopularity) pick(queries []string) string {
+	if popularity.ZipfExponent == 0 && len(popularity.Weights) == 0 {
+		return queries[rand.Intn(len(queries))]
+	}
+
+	total := 0.0
+	for rank, query := range queries {
+		total += popularity.weight(query, rank)
+	}
+	if total <= 0 {
+		return queries[rand.Intn(len(queries))]
+	}
+	roll := rand.Float64() * total
+	for rank, query := range queries {
+		roll -= popularity.weight(query, rank)
+		if roll < 0 {
+			return query
+		}
+	}
+	return queries[len(queries)-1]
+}
@@ -0,0 +1,61 @@
+package scenariolib_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestQueryPopularityValid(t *testing.T) {
+	valid, message := scenariolib.QueryPopularity{ZipfExponent: 1.2, Weights: map[string]float64{"rocky": 3}}.IsValid()
+	assert(t, valid, "Expected popularity to be valid, was false with error: %s", message)
+
+	valid, _ = scenariolib.QueryPopularity{ZipfExponent: -1}.IsValid()
+	assert(t, !valid, "Expected a negative zipfExponent to be invalid.")
+
+	valid, _ = scenariolib.QueryPopularity{Weights: map[string]float64{"rocky": -3}}.IsValid()
+	assert(t, !valid, "Expected a negative weight to be invalid.")
+
+	path := writeTestConfig(t, `{"queryPopularity": {"zipfExponent": -1}}`)
+	defer os.Remove(path)
+	_, err := scenariolib.NewConfigFromPath(path)
+	notok(t, err)
+}
+
+func TestQueryPopularitySearch(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// All requests caught by the Test server will be added to 'requests'
+	requests := make(map[string]RestRequest)
+
+	server := createTestServer(t, requests)
+	defer server.Close() // Close the server when test finishes
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.GoodQueries = []string{"rocky", "alien", "jaws"}
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	// Explicit weights have precedence over the Zipf distribution.
+	conf.QueryPopularity = scenariolib.QueryPopularity{ZipfExponent: 50, Weights: map[string]float64{"rocky": 0, "alien": 0}}
+	for i := 0; i < 20; i++ {
+		ok(t, (&scenariolib.SearchEvent{GoodQuery: true, IgnoreEvent: true}).Execute(v))
+		equals(t, "jaws", v.LastQuery.Q)
+	}
+
+	// With a steep Zipf distribution, the head of the pool is always picked.
+	conf.QueryPopularity = scenariolib.QueryPopularity{ZipfExponent: 50}
+	for i := 0; i < 20; i++ {
+		ok(t, (&scenariolib.SearchEvent{GoodQuery: true, IgnoreEvent: true}).Execute(v))
+		equals(t, "rocky", v.LastQuery.Q)
+	}
+}
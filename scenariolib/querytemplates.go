@@ -0,0 +1,101 @@
+package scenariolib
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// DEFAULTOPTIONALSLOTPROBABILITY The probability that an optional slot of a template is filled.
+const DEFAULTOPTIONALSLOTPROBABILITY float64 = 0.5
+
+// templateSlotRegex Matches the slots of a template such as {color}, or {size?} for an optional slot.
+var templateSlotRegex = regexp.MustCompile(`\{(\w+)(\?)?\}`)
+
+// QueryTemplates Templates of queries with named slots filled from lists of values,
+// such as "{color} {brand} {product}".
+// TemplatesInLang The templates of each language, used by searches matching the visit language
+// SlotsInLang     The values of the slots in each language, the Slots are used for the slots without values in the language
+type QueryTemplates struct {
+	Templates       []string                       `json:"templates,omitempty"`
+	TemplatesInLang map[string][]string            `json:"templatesInLanguage,omitempty"`
+	Slots           map[string][]string            `json:"slots,omitempty"`
+	SlotsInLang     map[string]map[string][]string `json:"slotsInLanguage,omitempty"`
+}
+
+// IsValid Validate that all the slots of the templates have values.
+func (templates *QueryTemplates) IsValid() (bool, string) {
+	for _, template := range templates.Templates {
+		if valid, message := templates.validTemplate(template, ""); !valid {
+			return false, message
+		}
+	}
+	for language, languageTemplates := range templates.TemplatesInLang {
+		for _, template := range languageTemplates {
+			if valid, message := templates.validTemplate(template, language); !valid {
+				return false, message
+			}
+		}
+	}
+	return true, ""
+}
+
+func (templates *QueryTemplates) validTemplate(template string, language string) (bool, string) {
+	for _, match := range templateSlotRegex.FindAllStringSubmatch(template, -1) {
+		if len(templates.slotValues(match[1], language)) == 0 {
+			return false, fmt.Sprintf("The slot %s of the query template \"%s\" has no values.", match[1], template)
+		}
+	}
+	return true, ""
+}
+
+// slotValues Returns the values of a slot in the language, or the values of the slot for all languages.
+func (templates *QueryTemplates) slotValues(name string, language string) []string {
+	if values := templates.SlotsInLang[language][name]; len(values) > 0 {
+		return values
+	}
+	return templates.Slots[name]
+}
+
+// Generate Returns a query from a random template with its slots filled with random values.
+// With matchLanguage, the templates and the values of the language are used.
+func (templates *QueryTemplates) Generate(language string, matchLanguage bool) (string, error) {
+	pool := templates.Templates
+	if matchLanguage {
+		pool = templates.TemplatesInLang[language]
+	} else {
+		language = ""
+	}
+	if len(pool) == 0 {
+		return "", errors.New("No query template to generate a query in " + language)
+	}
+	return templates.fill(pool[rand.Intn(len(pool))], language)
+}
+
+// fill Replace the slots of the template with random values.
+func (templates *QueryTemplates) fill(template string, language string) (string, error) {
+	var err error
+	query := templateSlotRegex.ReplaceAllStringFunc(template, func(slot string) string {
+		match := templateSlotRegex.FindStringSubmatch(slot)
+		if match[2] == "?" && rand.Float64() >= DEFAULTOPTIONALSLOTPROBABILITY {
+			return ""
+		}
+		values := templates.slotValues(match[1], language)
+		if len(values) == 0 {
+			err = fmt.Errorf("The slot %s has no values", match[1])
+			return ""
+		}
+		return values[rand.Intn(len(values))]
+	})
+	if err != nil {
+		return "", err
+	}
+	// Optional slots left out leave extra spaces behind.
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return "", fmt.Errorf("The query template \"%s\" generated an empty query", template)
+	}
+	return query, nil
+}
@@ -0,0 +1,82 @@
+package scenariolib_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestQueryTemplatesGenerate(t *testing.T) {
+	templates := &scenariolib.QueryTemplates{
+		Templates:       []string{"{color} {brand} {product} {size?}"},
+		TemplatesInLang: map[string][]string{"fr": {"{product} {brand} {color}"}},
+		Slots: map[string][]string{
+			"color":   {"red"},
+			"brand":   {"nike"},
+			"product": {"shoes"},
+			"size":    {"size 10"},
+		},
+		SlotsInLang: map[string]map[string][]string{"fr": {"color": {"rouges"}, "product": {"souliers"}}},
+	}
+	valid, message := templates.IsValid()
+	assert(t, valid, "Expected templates to be valid, was false with error: %s", message)
+
+	generated := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		query, err := templates.Generate("fr", false)
+		ok(t, err)
+		generated[query] = true
+	}
+	equals(t, map[string]bool{"red nike shoes": true, "red nike shoes size 10": true}, generated)
+
+	query, err := templates.Generate("fr", true)
+	ok(t, err)
+	equals(t, "souliers nike rouges", query)
+
+	_, err = templates.Generate("de", true)
+	notok(t, err)
+}
+
+func TestQueryTemplatesInvalid(t *testing.T) {
+	templates := &scenariolib.QueryTemplates{
+		TemplatesInLang: map[string][]string{"fr": {"{product} {brand}"}},
+		Slots:           map[string][]string{"product": {"shoes"}},
+	}
+	valid, _ := templates.IsValid()
+	assert(t, !valid, "Expected a template with a slot without values to be invalid.")
+
+	path := writeTestConfig(t, `{"queryTemplates": {"templates": ["{color} shoes"]}}`)
+	defer os.Remove(path)
+	_, err := scenariolib.NewConfigFromPath(path)
+	notok(t, err)
+}
+
+func TestSearchEventTemplateQuery(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// All requests caught by the Test server will be added to 'requests'
+	requests := make(map[string]RestRequest)
+
+	server := createTestServer(t, requests)
+	defer server.Close() // Close the server when test finishes
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.QueryTemplates = scenariolib.QueryTemplates{
+		Templates: []string{"{brand} {product}"},
+		Slots:     map[string][]string{"brand": {"nike"}, "product": {"shoes"}},
+	}
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	ok(t, (&scenariolib.SearchEvent{TemplateQuery: true, IgnoreEvent: true}).Execute(v))
+	equals(t, "nike shoes", v.LastQuery.Q)
+}
@@ -2,6 +2,18 @@ package scenariolib
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// SCENARIOKINDLINEAR A scenario executing its events in order.
+	SCENARIOKINDLINEAR string = "linear"
+	// SCENARIOKINDMARKOV A scenario walking a graph of states with transition probabilities.
+	SCENARIOKINDMARKOV string = "markov"
+	// DEFAULTMARKOVMAXSTEPS The maximum number of states visited in a markov scenario when not specified.
+	DEFAULTMARKOVMAXSTEPS int = 20
 )
 
 // Scenario Represents one visit to the search
@@ -23,12 +35,242 @@ type Scenario struct {
 
 	// Mobile A boolean value if this visit is forced on mobile
 	Mobile bool `json:"mobile,omitempty"`
+
+	// CustomData Custom data to send with every event of the visit.
+	CustomData map[string]interface{} `json:"customData,omitempty"`
+
+	// Extends The name of a scenario this scenario inherits from.
+	Extends string `json:"extends,omitempty"`
+
+	// DefaultOriginLevel1 Override of the config DefaultOriginLevel1 for this scenario.
+	DefaultOriginLevel1 string `json:"defaultOriginLevel1,omitempty"`
+
+	// DefaultOriginLevel2 Override of the config DefaultOriginLevel2 for this scenario.
+	DefaultOriginLevel2 string `json:"defaultOriginLevel2,omitempty"`
+
+	// DefaultOriginLevel3 Override of the config DefaultOriginLevel3 for this scenario.
+	DefaultOriginLevel3 string `json:"defaultOriginLevel3,omitempty"`
+
+	// Pipeline Override of the config Pipeline for this scenario.
+	Pipeline string `json:"pipeline,omitempty"`
+
+	// GlobalFilter Override of the config GlobalFilter for this scenario.
+	GlobalFilter string `json:"globalfilter,omitempty"`
+
+	// SearchHub Override of the config SearchHub for this scenario.
+	SearchHub string `json:"searchHub,omitempty"`
+
+	// Tab Override of the config Tab for this scenario.
+	Tab string `json:"tab,omitempty"`
+
+	// Context Added to the config Context for this scenario.
+	Context map[string]interface{} `json:"context,omitempty"`
+
+	// QueryDefaults Override of the config QueryDefaults values for this scenario.
+	QueryDefaults *QueryDefaults `json:"queryDefaults,omitempty"`
+
+	// SearchEndpoint Override of the config SearchEndpoint for this scenario.
+	SearchEndpoint string `json:"searchendpoint,omitempty"`
+
+	// AnalyticsEndpoint Override of the config AnalyticsEndpoint for this scenario.
+	AnalyticsEndpoint string `json:"analyticsendpoint,omitempty"`
+
+	// TimeBetweenActions Override of the config TimeBetweenActions for this scenario.
+	TimeBetweenActions int `json:"timeBetweenActions,omitempty"`
+
+	// AnonymousThreshold Override of the config AnonymousThreshold for this scenario.
+	AnonymousThreshold *float64 `json:"anonymousThreshold,omitempty"`
+
+	// RandomCustomData Added to the config RandomCustomData for this scenario, replacing the same apinames.
+	RandomCustomData []*RandomCustomData `json:"randomCustomData,omitempty"`
+
+	// Kind The kind of scenario, "linear" (default) or "markov".
+	Kind string `json:"kind,omitempty"`
+
+	// States The states of a markov scenario, by name.
+	States map[string]*ScenarioState `json:"states,omitempty"`
+
+	// StartState The state where a markov scenario begins.
+	StartState string `json:"startState,omitempty"`
+
+	// AbsorbingStates The states that end a markov scenario once executed.
+	AbsorbingStates []string `json:"absorbingStates,omitempty"`
+
+	// MaxSteps The maximum number of states visited in a markov scenario.
+	MaxSteps int `json:"maxSteps,omitempty"`
+}
+
+// ScenarioState One state of a markov scenario.
+// Event        The event executed when entering the state, nothing is executed if nil.
+// Transitions  The probability to go to each of the other states, the remainder is
+// the probability that the user leaves.
+type ScenarioState struct {
+	Event       *JSONEvent         `json:"event,omitempty"`
+	Transitions map[string]float64 `json:"transitions,omitempty"`
 }
 
 // JSONEvent An action taken by the user such as a search, a click, a SearchAndClick, etc.
 // Type A string describing the type of event
 // Arguments An array of the arguments to the event, specific to the type of event.
+// Capture Values to save in visit variables once the event is executed.
 type JSONEvent struct {
-	Type      string          `json:"type"`
-	Arguments json.RawMessage `json:"arguments"`
+	Type      string             `json:"type"`
+	Arguments json.RawMessage    `json:"arguments"`
+	Capture   []*VariableCapture `json:"capture,omitempty"`
+}
+
+// IsValid Validate the scenario, mostly the state graph of markov scenarios.
+func (scenario *Scenario) IsValid() (bool, string) {
+	if scenario.AnonymousThreshold != nil && (*scenario.AnonymousThreshold < 0 || *scenario.AnonymousThreshold > 1) {
+		return false, "anonymousThreshold must be between 0 and 1."
+	}
+
+	switch scenario.Kind {
+	case "", SCENARIOKINDLINEAR:
+		return true, ""
+	case SCENARIOKINDMARKOV:
+	default:
+		return false, fmt.Sprintf("Scenario kind %s is not supported.", scenario.Kind)
+	}
+
+	if _, ok := scenario.States[scenario.StartState]; !ok {
+		return false, fmt.Sprintf("The startState %s is not defined in the states.", scenario.StartState)
+	}
+	if scenario.MaxSteps < 0 {
+		return false, "maxSteps must be a positive integer."
+	}
+	for _, absorbing := range scenario.AbsorbingStates {
+		if _, ok := scenario.States[absorbing]; !ok {
+			return false, fmt.Sprintf("The absorbing state %s is not defined in the states.", absorbing)
+		}
+	}
+	for name, state := range scenario.States {
+		if state == nil {
+			return false, fmt.Sprintf("The state %s is empty.", name)
+		}
+		total := 0.0
+		for next, probability := range state.Transitions {
+			if _, ok := scenario.States[next]; !ok {
+				return false, fmt.Sprintf("The state %s has a transition to undefined state %s.", name, next)
+			}
+			if probability < 0 || probability > 1 {
+				return false, fmt.Sprintf("The transition from %s to %s must have a probability between 0 and 1.", name, next)
+			}
+			total += probability
+		}
+		if total > 1+1e-9 {
+			return false, fmt.Sprintf("The transitions of state %s have probabilities adding up to more than 1.", name)
+		}
+	}
+	return true, ""
+}
+
+// inherit Copy from the base scenario the values not set on this scenario: language, mobile,
+// user agent, settings overrides and custom data. The events of the base are executed first in
+// linear scenarios.
+func (scenario *Scenario) inherit(base *Scenario) {
+	if scenario.Language == "" {
+		scenario.Language = base.Language
+	}
+	if !scenario.Mobile {
+		scenario.Mobile = base.Mobile
+	}
+	if scenario.UserAgent == "" {
+		scenario.UserAgent = base.UserAgent
+	}
+	if scenario.DefaultOriginLevel1 == "" {
+		scenario.DefaultOriginLevel1 = base.DefaultOriginLevel1
+	}
+	if scenario.DefaultOriginLevel2 == "" {
+		scenario.DefaultOriginLevel2 = base.DefaultOriginLevel2
+	}
+	if scenario.DefaultOriginLevel3 == "" {
+		scenario.DefaultOriginLevel3 = base.DefaultOriginLevel3
+	}
+	if scenario.Pipeline == "" {
+		scenario.Pipeline = base.Pipeline
+	}
+	if scenario.GlobalFilter == "" {
+		scenario.GlobalFilter = base.GlobalFilter
+	}
+	if scenario.SearchHub == "" {
+		scenario.SearchHub = base.SearchHub
+	}
+	if scenario.Tab == "" {
+		scenario.Tab = base.Tab
+	}
+	scenario.Context = mergeContext(base.Context, scenario.Context)
+	if base.QueryDefaults != nil {
+		queryDefaults := base.QueryDefaults.merge(scenario.QueryDefaults)
+		scenario.QueryDefaults = &queryDefaults
+	}
+	if scenario.SearchEndpoint == "" {
+		scenario.SearchEndpoint = base.SearchEndpoint
+	}
+	if scenario.AnalyticsEndpoint == "" {
+		scenario.AnalyticsEndpoint = base.AnalyticsEndpoint
+	}
+	if scenario.TimeBetweenActions == 0 {
+		scenario.TimeBetweenActions = base.TimeBetweenActions
+	}
+	if scenario.AnonymousThreshold == nil {
+		scenario.AnonymousThreshold = base.AnonymousThreshold
+	}
+	scenario.RandomCustomData = mergeRandomCustomData(base.RandomCustomData, scenario.RandomCustomData)
+	if len(base.CustomData) > 0 {
+		customData := make(map[string]interface{})
+		for k, v := range base.CustomData {
+			customData[k] = v
+		}
+		for k, v := range scenario.CustomData {
+			customData[k] = v
+		}
+		scenario.CustomData = customData
+	}
+	if scenario.Kind != SCENARIOKINDMARKOV && base.Kind != SCENARIOKINDMARKOV {
+		scenario.Events = append(append([]JSONEvent{}, base.Events...), scenario.Events...)
+	}
+}
+
+// isAbsorbing Returns true if the state ends the markov scenario.
+func (scenario *Scenario) isAbsorbing(state string) bool {
+	for _, absorbing := range scenario.AbsorbingStates {
+		if absorbing == state {
+			return true
+		}
+	}
+	return false
+}
+
+// nextState Randomize the next state following the transitions of the current state,
+// returns an empty string when the user leaves.
+func (scenario *Scenario) nextState(current string) string {
+	state := scenario.States[current]
+	if state == nil || len(state.Transitions) == 0 {
+		return ""
+	}
+
+	// Iterate in a stable order so a given seed always walks the same path.
+	names := make([]string, 0, len(state.Transitions))
+	for name := range state.Transitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	roll := rand.Float64()
+	for _, name := range names {
+		roll -= state.Transitions[name]
+		if roll < 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+// maxSteps Returns the maximum number of states to visit in a markov scenario.
+func (scenario *Scenario) maxSteps() int {
+	if scenario.MaxSteps > 0 {
+		return scenario.MaxSteps
+	}
+	return DEFAULTMARKOVMAXSTEPS
 }
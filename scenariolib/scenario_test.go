@@ -0,0 +1,108 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestMarkovScenarioValid(t *testing.T) {
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/MarkovScenarios.json")
+	ok(t, err)
+
+	equals(t, 1, len(conf.Scenarios))
+	scenario := conf.Scenarios[0]
+
+	equals(t, scenariolib.SCENARIOKINDMARKOV, scenario.Kind)
+	equals(t, "search", scenario.StartState)
+	equals(t, []string{"leave"}, scenario.AbsorbingStates)
+	equals(t, 12, scenario.MaxSteps)
+	equals(t, 0.45, scenario.States["search"].Transitions["click"])
+	equals(t, "Click", scenario.States["click"].Event.Type)
+	assert(t, scenario.States["leave"].Event == nil, "Expected the leave state to have no event.")
+}
+
+func TestMarkovScenarioInvalid(t *testing.T) {
+	var testScenarios = map[string][]byte{
+		"unknown start state":     []byte(`{"kind": "markov", "startState": "nope", "states": {"search": {}}}`),
+		"unknown transition":      []byte(`{"kind": "markov", "startState": "search", "states": {"search": {"transitions": {"nope": 0.5}}}}`),
+		"probabilities above 1":   []byte(`{"kind": "markov", "startState": "search", "states": {"search": {"transitions": {"search": 0.7, "click": 0.7}}, "click": {}}}`),
+		"unknown absorbing state": []byte(`{"kind": "markov", "startState": "search", "absorbingStates": ["nope"], "states": {"search": {}}}`),
+		"unsupported kind":        []byte(`{"kind": "circular"}`),
+	}
+
+	for name, testScenarioJSON := range testScenarios {
+		scenario := &scenariolib.Scenario{}
+		err := json.Unmarshal(testScenarioJSON, scenario)
+		ok(t, err)
+
+		valid, _ := scenario.IsValid()
+		assert(t, !valid, "Expected scenario with %s to be invalid.", name)
+	}
+}
+
+func TestMarkovScenarioExecute(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// Keep the query of every search event sent to the analytics.
+	queries := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case defaults.SEARCH_REST_PATH:
+			rw.Write([]byte(`{"totalCount": 1, "results": [{"uri": "uri", "raw": {"urihash": "hash"}}]}`))
+			return
+		case defaults.ANALYTICS_REST_PATH + "search/":
+			body := map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&body)
+			queries = append(queries, body["queryText"].(string))
+		}
+		rw.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+	v.WaitBetweenActions = false
+
+	// The transitions are certain, or never taken, so every walk is deterministic.
+	scenario := scenariolib.Scenario{}
+	ok(t, json.Unmarshal([]byte(`{"kind": "markov", "startState": "first", "absorbingStates": ["third"], "states": {
+		"first": {"event": {"type": "Search", "arguments": {"queryText": "first"}}, "transitions": {"second": 1, "third": 0}},
+		"second": {"event": {"type": "Search", "arguments": {"queryText": "second"}}, "transitions": {"third": 1}},
+		"third": {"event": {"type": "Search", "arguments": {"queryText": "third"}}, "transitions": {"first": 1}},
+		"loop": {"event": {"type": "Search", "arguments": {"queryText": "loop"}}, "transitions": {"loop": 1}},
+		"alone": {"event": {"type": "Search", "arguments": {"queryText": "alone"}}, "transitions": {"first": 0}}
+	}}`), &scenario))
+	valid, message := scenario.IsValid()
+	assert(t, valid, "Expected scenario to be valid, was false with error: %s", message)
+
+	// The absorbing state is executed, then ends the walk despite its transition.
+	ok(t, v.ExecuteScenario(scenario, conf))
+	equals(t, []string{"first", "second", "third"}, queries)
+
+	// A walk that never ends stops after maxSteps states.
+	queries = queries[:0]
+	scenario.StartState = "loop"
+	scenario.MaxSteps = 3
+	ok(t, v.ExecuteScenario(scenario, conf))
+	equals(t, []string{"loop", "loop", "loop"}, queries)
+
+	// The user leaves with the remainder of the probabilities.
+	queries = queries[:0]
+	scenario.StartState = "alone"
+	ok(t, v.ExecuteScenario(scenario, conf))
+	equals(t, []string{"alone"}, queries)
+}
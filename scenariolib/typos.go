@@ -0,0 +1,190 @@
+package scenariolib
+
+import (
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+const (
+	// TYPOSUBSTITUTION Replace a letter with a letter next to it on the keyboard.
+	TYPOSUBSTITUTION string = "substitution"
+	// TYPOTRANSPOSITION Swap two consecutive letters.
+	TYPOTRANSPOSITION string = "transposition"
+	// TYPODELETION Drop a letter.
+	TYPODELETION string = "deletion"
+	// TYPODOUBLING Type a letter twice.
+	TYPODOUBLING string = "doubling"
+	// TYPOPHONETIC Replace letters with others that sound the same.
+	TYPOPHONETIC string = "phonetic"
+	// DEFAULTTYPOSPERQUERY The number of typos in a query when not specified.
+	DEFAULTTYPOSPERQUERY int = 1
+)
+
+// TypoRates The relative rates of each kind of typo and the number of typos in a query.
+// The kinds of typos are equally likely when no rate is specified.
+type TypoRates struct {
+	Substitution  float64 `json:"substitution,omitempty"`
+	Transposition float64 `json:"transposition,omitempty"`
+	Deletion      float64 `json:"deletion,omitempty"`
+	Doubling      float64 `json:"doubling,omitempty"`
+	Phonetic      float64 `json:"phonetic,omitempty"`
+	PerQuery      int     `json:"perQuery,omitempty"`
+}
+
+// keyboardNeighbors The letters next to each letter on a qwerty keyboard.
+var keyboardNeighbors = map[rune]string{
+	'q': "wa", 'w': "qeas", 'e': "wrsd", 'r': "etdf", 't': "ryfg", 'y': "tugh", 'u': "yihj", 'i': "uojk", 'o': "ipkl", 'p': "ol",
+	'a': "qwsz", 's': "awedxz", 'd': "serfcx", 'f': "drtgvc", 'g': "ftyhbv", 'h': "gyujnb", 'j': "huikmn", 'k': "jiolm", 'l': "kop",
+	'z': "asx", 'x': "zsdc", 'c': "xdfv", 'v': "cfgb", 'b': "vghn", 'n': "bhjm", 'm': "njk",
+}
+
+// phoneticSwaps Groups of letters that sound the same, each one can replace the other.
+var phoneticSwaps = [][2]string{
+	{"ph", "f"}, {"ck", "k"}, {"c", "k"}, {"s", "z"}, {"ie", "ei"}, {"ou", "u"}, {"ee", "ea"}, {"y", "i"}, {"gh", "g"}, {"qu", "kw"},
+}
+
+// Mutate Returns the query with typos, it is returned unchanged if no typo applies to it.
+func (rates TypoRates) Mutate(query string) string {
+	perQuery := rates.PerQuery
+	if perQuery <= 0 {
+		perQuery = DEFAULTTYPOSPERQUERY
+	}
+	mutated := query
+	for i := 0; i < perQuery; i++ {
+		mutated = rates.mutateOnce(mutated)
+	}
+	return mutated
+}
+
+// mutateOnce Apply one typo, of a kind randomized with the rates among the kinds that apply to the query.
+func (rates TypoRates) mutateOnce(query string) string {
+	weights := map[string]float64{
+		TYPOSUBSTITUTION:  rates.Substitution,
+		TYPOTRANSPOSITION: rates.Transposition,
+		TYPODELETION:      rates.Deletion,
+		TYPODOUBLING:      rates.Doubling,
+		TYPOPHONETIC:      rates.Phonetic,
+	}
+	kinds := []string{TYPOSUBSTITUTION, TYPOTRANSPOSITION, TYPODELETION, TYPODOUBLING, TYPOPHONETIC}
+	total := 0.0
+	for _, kind := range kinds {
+		total += weights[kind]
+	}
+	if total <= 0 {
+		for _, kind := range kinds {
+			weights[kind] = 1
+		}
+	}
+
+	candidates := map[string]string{}
+	total = 0
+	for _, kind := range kinds {
+		if weights[kind] <= 0 {
+			continue
+		}
+		if mutated := applyTypo(kind, query); mutated != query {
+			candidates[kind] = mutated
+			total += weights[kind]
+		}
+	}
+	roll := rand.Float64() * total
+	for _, kind := range kinds {
+		if mutated, ok := candidates[kind]; ok {
+			roll -= weights[kind]
+			if roll < 0 {
+				return mutated
+			}
+		}
+	}
+	return query
+}
+
+// applyTypo Apply a typo of the given kind at a random position of the query.
+func applyTypo(kind string, query string) string {
+	runes := []rune(query)
+	letters := []int{}
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			letters = append(letters, i)
+		}
+	}
+	if len(letters) == 0 {
+		return query
+	}
+
+	switch kind {
+	case TYPOSUBSTITUTION:
+		substitutable := []int{}
+		for _, i := range letters {
+			if _, ok := keyboardNeighbors[unicode.ToLower(runes[i])]; ok {
+				substitutable = append(substitutable, i)
+			}
+		}
+		if len(substitutable) == 0 {
+			return query
+		}
+		i := substitutable[rand.Intn(len(substitutable))]
+		neighbors := []rune(keyboardNeighbors[unicode.ToLower(runes[i])])
+		neighbor := neighbors[rand.Intn(len(neighbors))]
+		if unicode.IsUpper(runes[i]) {
+			neighbor = unicode.ToUpper(neighbor)
+		}
+		runes[i] = neighbor
+	case TYPOTRANSPOSITION:
+		swappable := []int{}
+		for _, i := range letters {
+			if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) && runes[i] != runes[i+1] {
+				swappable = append(swappable, i)
+			}
+		}
+		if len(swappable) == 0 {
+			return query
+		}
+		i := swappable[rand.Intn(len(swappable))]
+		runes[i], runes[i+1] = runes[i+1], runes[i]
+	case TYPODELETION:
+		if len(letters) < 2 {
+			return query
+		}
+		i := letters[rand.Intn(len(letters))]
+		runes = append(runes[:i:i], runes[i+1:]...)
+	case TYPODOUBLING:
+		i := letters[rand.Intn(len(letters))]
+		runes = append(runes[:i+1:i+1], runes[i:]...)
+	case TYPOPHONETIC:
+		return phoneticTypo(query)
+	}
+	return string(runes)
+}
+
+// phoneticTypo Replace one group of letters of the query with a group that sounds the same.
+func phoneticTypo(query string) string {
+	lower := strings.ToLower(query)
+	if len(lower) != len(query) {
+		return query
+	}
+	type swap struct {
+		index int
+		from  string
+		to    string
+	}
+	swaps := []swap{}
+	for _, pair := range phoneticSwaps {
+		for _, direction := range [][2]string{{pair[0], pair[1]}, {pair[1], pair[0]}} {
+			for offset := 0; ; {
+				i := strings.Index(lower[offset:], direction[0])
+				if i < 0 {
+					break
+				}
+				swaps = append(swaps, swap{offset + i, direction[0], direction[1]})
+				offset += i + len(direction[0])
+			}
+		}
+	}
+	if len(swaps) == 0 {
+		return query
+	}
+	s := swaps[rand.Intn(len(swaps))]
+	return query[:s.index] + s.to + query[s.index+len(s.from):]
+}
@@ -0,0 +1,74 @@
+package scenariolib_test
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/coveo/uabot/defaults"
+	"github.com/coveo/uabot/scenariolib"
+)
+
+func TestTypoRatesMutate(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		deleted := scenariolib.TypoRates{Deletion: 1}.Mutate("ghostbusters")
+		equals(t, 11, len(deleted))
+
+		doubled := scenariolib.TypoRates{Doubling: 1, PerQuery: 2}.Mutate("ghostbusters")
+		equals(t, 14, len(doubled))
+
+		transposed := scenariolib.TypoRates{Transposition: 1}.Mutate("ghostbusters")
+		assert(t, transposed != "ghostbusters", "Expected a transposition in %s", transposed)
+		equals(t, sortedLetters("ghostbusters"), sortedLetters(transposed))
+
+		substituted := scenariolib.TypoRates{Substitution: 1}.Mutate("Rocky")
+		equals(t, 5, len(substituted))
+		differences := 0
+		for j := range substituted {
+			if substituted[j] != "Rocky"[j] {
+				differences++
+			}
+		}
+		equals(t, 1, differences)
+
+		equals(t, "fone", scenariolib.TypoRates{Phonetic: 1}.Mutate("phone"))
+	}
+
+	// Without letters, or without a typo that applies, the query is unchanged.
+	equals(t, "1984", scenariolib.TypoRates{}.Mutate("1984"))
+	equals(t, "tv", scenariolib.TypoRates{Phonetic: 1}.Mutate("tv"))
+}
+
+func sortedLetters(s string) string {
+	letters := strings.Split(s, "")
+	sort.Strings(letters)
+	return strings.Join(letters, "")
+}
+
+func TestSearchEventTypoQuery(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	// All requests caught by the Test server will be added to 'requests'
+	requests := make(map[string]RestRequest)
+
+	server := createTestServer(t, requests)
+	defer server.Close() // Close the server when test finishes
+
+	conf, err := scenariolib.NewConfigFromPath("../scenarios_examples/TESTScenarios.json")
+	ok(t, err)
+
+	// Use the server url to define the endpoints
+	conf.SearchEndpoint = server.URL + defaults.SEARCH_REST_PATH
+	conf.AnalyticsEndpoint = server.URL + defaults.ANALYTICS_REST_PATH
+	conf.GoodQueries = []string{"ghostbusters"}
+	conf.Typos = scenariolib.TypoRates{Deletion: 1}
+
+	v, err := scenariolib.NewVisit("bot.searchToken", "bot.analyticsToken", "scenario.UserAgent", "en", conf)
+	ok(t, err)
+	v.SetupGeneral()
+
+	event := &scenariolib.SearchEvent{TypoQuery: true, IgnoreEvent: true}
+	ok(t, event.Execute(v))
+	equals(t, 11, len(v.LastQuery.Q))
+}
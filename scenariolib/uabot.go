@@ -4,6 +4,8 @@ import (
 	"errors"
 	"math/rand"
 	"time"
+
+	"github.com/coveooss/go-coveo/search"
 )
 
 // DEFAULTTIMEBETWEENVISITS The time for the bot to wait between visits, between 0 and X Seconds
@@ -59,6 +61,15 @@ func (bot *uabot) Run(quitChannel chan bool) error {
 
 	bot.WaitBetweenVisits = !conf.DontWaitBetweenVisits
 
+	// Harvest queries from the index before the first visit, then refresh them between visits.
+	var lastHarvest time.Time
+	if conf.Harvest.Enabled {
+		if err = bot.harvestQueries(conf); err != nil {
+			return err
+		}
+		lastHarvest = time.Now()
+	}
+
 	// Refresh the scenario files every 5 hours automatically.
 	// This way, no need to stop the bot to update the possible scenarios.
 	bot.continuallyRefreshScenariosEvery(5*time.Hour, conf)
@@ -75,6 +86,13 @@ func (bot *uabot) Run(quitChannel chan bool) error {
 		select { // select on the quitChannel
 		default: // default means there is no quit signal
 
+			if conf.Harvest.needsRefresh(lastHarvest) {
+				if err := bot.harvestQueries(conf); err != nil {
+					Warning.Printf("Cannot harvest queries, keeping the old ones : %v", err)
+				}
+				lastHarvest = time.Now()
+			}
+
 			scenario, err := randomScenario(conf.ScenarioMap)
 			if err != nil {
 				return err
@@ -91,19 +109,19 @@ func (bot *uabot) Run(quitChannel chan bool) error {
 				}
 			}
 
+			// The session settings of the visit, overridden by the scenario
+			visitConf := conf.ForScenario(scenario)
+
 			// New visit
-			visit, err := NewVisit(bot.searchToken, bot.analyticsToken, scenario.UserAgent, scenario.Language, conf)
+			visit, err := NewVisit(bot.searchToken, bot.analyticsToken, scenario.UserAgent, scenario.Language, visitConf)
 			if err != nil {
 				return err
 			}
 
-			// Setup specific stuff for NTO
-			//visit.SetupNTO()
-			// Use this line instead outside of NTO
 			visit.SetupGeneral()
-			visit.LastQuery.CQ = conf.GlobalFilter
+			visit.LastQuery.CQ = visitConf.GlobalFilter
 
-			err = visit.ExecuteScenario(*scenario, conf)
+			err = visit.ExecuteScenario(*scenario, visitConf)
 			if err != nil {
 				Warning.Print(err)
 			}
@@ -124,6 +142,19 @@ func (bot *uabot) Run(quitChannel chan bool) error {
 	}
 }
 
+// harvestQueries Harvest the good and bad queries of the config from the index.
+func (bot *uabot) harvestQueries(conf *Config) error {
+	userAgent, err := randomUserAgent(conf.RandomData.UserAgents)
+	if err != nil {
+		return err
+	}
+	client, err := search.NewClient(search.Config{Token: bot.searchToken, UserAgent: userAgent, Endpoint: conf.SearchEndpoint})
+	if err != nil {
+		return err
+	}
+	return conf.HarvestQueries(client)
+}
+
 func (bot *uabot) continuallyUpdateTimeVisitsEvery(timeDuration time.Duration, timeVisits *int) {
 	ticker := time.NewTicker(timeDuration)
 	go func() {
@@ -0,0 +1,185 @@
+package scenariolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coveooss/go-coveo/search"
+)
+
+const (
+	// CAPTUREFROMRESULT Capture the value of a field of a result of the last response.
+	CAPTUREFROMRESULT string = "result"
+	// CAPTUREFROMTITLE Capture the title of a result of the last response.
+	CAPTUREFROMTITLE string = "title"
+	// CAPTUREFROMQUERY Capture the last query text.
+	CAPTUREFROMQUERY string = "query"
+	// CAPTUREFROMRANDOM Capture a random value from a list.
+	CAPTUREFROMRANDOM string = "random"
+	// CAPTURECLICKEDRANK The rank to use to capture from the last clicked result.
+	CAPTURECLICKEDRANK int = -1
+)
+
+// variableTemplate Matches a reference to a visit variable such as ${author}
+var variableTemplate = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// VariableCapture Describes a value to save in a visit variable after an event is executed.
+// Name   The name of the variable, referenced as ${name} in the arguments of the following events
+// From   Where to take the value from: result, title, query or random
+// Rank   The rank of the result (0 based) to capture from, -1 for the last clicked result
+// Field  The field of the result to capture (only used with result)
+// Values The values to randomize from (only used with random)
+type VariableCapture struct {
+	Name   string   `json:"name"`
+	From   string   `json:"from"`
+	Rank   int      `json:"rank,omitempty"`
+	Field  string   `json:"field,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+// IsValid Additional validation after the json unmarshal.
+func (capture *VariableCapture) IsValid() (bool, string) {
+	if !variableTemplate.MatchString("${" + capture.Name + "}") {
+		return false, "A captured variable needs a name made of letters, digits and underscores."
+	}
+	switch capture.From {
+	case CAPTUREFROMRESULT:
+		if capture.Field == "" {
+			return false, "A capture from a result needs a field."
+		}
+	case CAPTUREFROMTITLE, CAPTUREFROMQUERY:
+	case CAPTUREFROMRANDOM:
+		if len(capture.Values) == 0 {
+			return false, "A random capture needs values to randomize from."
+		}
+	default:
+		return false, fmt.Sprintf("Cannot capture a variable from %s.", capture.From)
+	}
+	if capture.Rank < CAPTURECLICKEDRANK {
+		return false, "Capture rank must be -1 or >= 0."
+	}
+	return true, ""
+}
+
+// CaptureVariables Save the values described by the captures in the visit variables.
+func (v *Visit) CaptureVariables(captures []*VariableCapture) error {
+	for _, capture := range captures {
+		var value string
+		switch capture.From {
+		case CAPTUREFROMQUERY:
+			if v.LastQuery == nil {
+				return fmt.Errorf("Cannot capture %s, no query was executed", capture.Name)
+			}
+			value = v.LastQuery.Q
+		case CAPTUREFROMRANDOM:
+			value = randomStringArray(capture.Values)
+		default:
+			result, err := v.captureResult(capture)
+			if err != nil {
+				return err
+			}
+			if capture.From == CAPTUREFROMTITLE {
+				value = result.Title
+			} else {
+				rawValue := getFieldValueFromRaw(result.Raw, strings.TrimPrefix(capture.Field, "@"))
+				if rawValue == nil {
+					return fmt.Errorf("Cannot capture %s, field %s does not exist on the result", capture.Name, capture.Field)
+				}
+				value = rawValueToString(rawValue)
+			}
+		}
+		Trace.Printf("Capturing variable %s = \"%s\"", capture.Name, value)
+		v.Variables[capture.Name] = value
+	}
+	return nil
+}
+
+// captureResult Returns the result to capture from, either the last clicked result
+// or a result of the last response.
+func (v *Visit) captureResult(capture *VariableCapture) (*search.Result, error) {
+	if capture.Rank == CAPTURECLICKEDRANK {
+		if v.LastClickedResult == nil {
+			return nil, fmt.Errorf("Cannot capture %s, no result was clicked", capture.Name)
+		}
+		return v.LastClickedResult, nil
+	}
+	if v.LastResponse == nil {
+		return nil, fmt.Errorf("Cannot capture %s, LastResponse is nil", capture.Name)
+	}
+	if capture.Rank >= len(v.LastResponse.Results) {
+		return nil, fmt.Errorf("Cannot capture %s, no result at rank %d", capture.Name, capture.Rank+1)
+	}
+	return &v.LastResponse.Results[capture.Rank], nil
+}
+
+// rawValueToString Convert a raw field value to a string, keeping the first value of multi-value fields.
+func rawValueToString(rawValue interface{}) string {
+	switch value := rawValue.(type) {
+	case string:
+		return value
+	case []interface{}:
+		if len(value) == 0 {
+			return ""
+		}
+		return rawValueToString(value[0])
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// ResolveVariables Replace the references to visit variables (${name}) in all the string values
+// of the arguments of an event. Returns an error if a referenced variable was never captured.
+func (v *Visit) ResolveVariables(arguments json.RawMessage) (json.RawMessage, error) {
+	if !variableTemplate.Match(arguments) {
+		return arguments, nil
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(arguments, &parsed); err != nil {
+		return nil, err
+	}
+	resolved, err := v.resolveValue(parsed)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resolved)
+}
+
+func (v *Visit) resolveValue(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return v.resolveString(typed)
+	case []interface{}:
+		for i := range typed {
+			resolved, err := v.resolveValue(typed[i])
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = resolved
+		}
+	case map[string]interface{}:
+		for k := range typed {
+			resolved, err := v.resolveValue(typed[k])
+			if err != nil {
+				return nil, err
+			}
+			typed[k] = resolved
+		}
+	}
+	return value, nil
+}
+
+func (v *Visit) resolveString(value string) (string, error) {
+	var err error
+	resolved := variableTemplate.ReplaceAllStringFunc(value, func(reference string) string {
+		name := variableTemplate.FindStringSubmatch(reference)[1]
+		variable, ok := v.Variables[name]
+		if !ok {
+			err = fmt.Errorf("Variable %s is not defined in this visit", name)
+			return reference
+		}
+		return variable
+	})
+	return resolved, err
+}
@@ -0,0 +1,85 @@
+package scenariolib_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/coveo/uabot/scenariolib"
+	"github.com/coveooss/go-coveo/search"
+)
+
+func TestCaptureVariables(t *testing.T) {
+	scenariolib.InitLogger(os.Stdout, os.Stdout, os.Stdout, os.Stderr)
+
+	var testCaptureJson = []byte(`[
+		{"name": "author", "from": "result", "rank": 1, "field": "@author"},
+		{"name": "clicked", "from": "title", "rank": -1},
+		{"name": "query", "from": "query"},
+		{"name": "color", "from": "random", "values": ["red"]}
+	]`)
+	captures := []*scenariolib.VariableCapture{}
+
+	// Test unmarshal json.
+	err := json.Unmarshal(testCaptureJson, &captures)
+	ok(t, err)
+
+	for _, capture := range captures {
+		valid, message := capture.IsValid()
+		assert(t, valid, "Expected capture to be valid, was false with error: %s", message)
+	}
+
+	v := &scenariolib.Visit{
+		Variables: make(map[string]string),
+		LastQuery: &search.Query{Q: "queryTest"},
+		LastResponse: &search.Response{
+			TotalCount: 2,
+			Results: []search.Result{
+				{Title: "First", Raw: map[string]interface{}{"author": "Jane"}},
+				{Title: "Second", Raw: map[string]interface{}{"sysauthor": []interface{}{"John", "Jane"}}},
+			},
+		},
+	}
+	v.LastClickedResult = &v.LastResponse.Results[0]
+
+	err = v.CaptureVariables(captures)
+	ok(t, err)
+
+	equals(t, "John", v.Variables["author"])
+	equals(t, "First", v.Variables["clicked"])
+	equals(t, "queryTest", v.Variables["query"])
+	equals(t, "red", v.Variables["color"])
+}
+
+func TestCaptureInvalid(t *testing.T) {
+	var testCaptureJson = []byte(`[
+		{"name": "author", "from": "result"},
+		{"name": "bad name", "from": "query"},
+		{"name": "color", "from": "random"},
+		{"name": "other", "from": "somewhere"}
+	]`)
+	captures := []*scenariolib.VariableCapture{}
+
+	// Test unmarshal json.
+	err := json.Unmarshal(testCaptureJson, &captures)
+	ok(t, err)
+
+	for _, capture := range captures {
+		valid, _ := capture.IsValid()
+		assert(t, !valid, "Expected capture of %s to be invalid.", capture.Name)
+	}
+}
+
+func TestResolveVariables(t *testing.T) {
+	v := &scenariolib.Visit{Variables: map[string]string{"author": "Jane \"JD\" Doe", "title": "Rocky"}}
+
+	resolved, err := v.ResolveVariables([]byte(`{"queryText": "${author}", "matchPattern": "^${title}$", "customData": {"from": "${title}"}, "probability": 1}`))
+	ok(t, err)
+
+	eq, err := JSONBytesEqual([]byte(`{"queryText": "Jane \"JD\" Doe", "matchPattern": "^Rocky$", "customData": {"from": "Rocky"}, "probability": 1}`), resolved)
+	ok(t, err)
+	assert(t, eq, "The resolved arguments are not what we expected\nGot: %s", resolved)
+
+	_, err = v.ResolveVariables([]byte(`{"queryText": "${unknown}"}`))
+	notok(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"math/rand"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +27,11 @@ import (
 // OriginLevel3 The HTTP identifier of the page from which any type of event originates
 // Referrer     Same as OriginLevel3
 // LastTab      The tab the user last visited
+// LastClickedResult The last result the user clicked on
+// Variables    The values captured by the events of the visit, by name
+// CustomData   The custom data of the scenario, sent with every event
+// Context      The context sent with the queries, reflected in the customData of the events
+// FacetState   The facet values selected by the user
 type Visit struct {
 	SearchClient       search.Client
 	UAClient           ua.Client
@@ -42,6 +48,11 @@ type Visit struct {
 	Anonymous          bool
 	Language           string
 	WaitBetweenActions bool
+	LastClickedResult  *search.Result
+	Variables          map[string]string
+	CustomData         map[string]interface{}
+	Context            map[string]interface{}
+	FacetState         FacetState
 }
 
 const (
@@ -61,6 +72,7 @@ func NewVisit(_searchtoken string, _uatoken string, _useragent string, language
 
 	v := Visit{}
 	v.Config = c
+	v.Variables = make(map[string]string)
 
 	v.WaitBetweenActions = !c.DontWaitBetweenVisits
 	v.Anonymous = false
@@ -122,24 +134,70 @@ func randomStringArray(array []string) (value string) {
 // potential random we need to do.
 func (v *Visit) ExecuteScenario(scenario Scenario, c *Config) error {
 	Info.Printf("Executing scenario named : %s", scenario.Name)
+	v.CustomData = scenario.CustomData
+	if scenario.Kind == SCENARIOKINDMARKOV {
+		return v.executeMarkovScenario(scenario, c)
+	}
 	for i := 0; i < len(scenario.Events); i++ {
-		jsonEvent := scenario.Events[i]
-		event, err := ParseEvent(&jsonEvent, c)
-		if err != nil {
-			return err
-		}
-		err = event.Execute(v)
-		if err != nil {
+		if err := v.executeEvent(&scenario.Events[i], c); err != nil {
 			return err
 		}
-		if v.WaitBetweenActions {
-			if c.TimeBetweenActions > 0 {
-				WaitBetweenActions(c.TimeBetweenActions, c.IsWaitConstant)
-			} else {
-				WaitBetweenActions(DEFAULTTIMEBETWEENACTIONS, c.IsWaitConstant)
+	}
+	return nil
+}
+
+// executeMarkovScenario Walk the state graph of the scenario from its start state,
+// executing the event of each state, until the user leaves, an absorbing state is
+// reached or the maximum number of steps is done.
+func (v *Visit) executeMarkovScenario(scenario Scenario, c *Config) error {
+	state := scenario.StartState
+	maxSteps := scenario.maxSteps()
+	for step := 0; step < maxSteps && state != ""; step++ {
+		Info.Printf("Entering state %s (step %d)", state, step+1)
+		if jsonEvent := scenario.States[state].Event; jsonEvent != nil {
+			if err := v.executeEvent(jsonEvent, c); err != nil {
+				return err
 			}
+		}
+		if scenario.isAbsorbing(state) {
+			Info.Printf("State %s ends the visit", state)
+			return nil
+		}
+		state = scenario.nextState(state)
+	}
+	if state == "" {
+		Info.Println("User left the visit")
+	}
+	return nil
+}
 
+// executeEvent Resolve the visit variables in the event arguments, parse and execute
+// the event, capture new variables, then wait before the next action.
+func (v *Visit) executeEvent(jsonEvent *JSONEvent, c *Config) error {
+	arguments, err := v.ResolveVariables(jsonEvent.Arguments)
+	if err != nil {
+		return err
+	}
+	resolvedEvent := *jsonEvent
+	resolvedEvent.Arguments = arguments
+	event, err := ParseEvent(&resolvedEvent, c)
+	if err != nil {
+		return err
+	}
+	err = event.Execute(v)
+	if err != nil {
+		return err
+	}
+	if err = v.CaptureVariables(jsonEvent.Capture); err != nil {
+		return err
+	}
+	if v.WaitBetweenActions {
+		if c.TimeBetweenActions > 0 {
+			WaitBetweenActions(c.TimeBetweenActions, c.IsWaitConstant)
+		} else {
+			WaitBetweenActions(DEFAULTTIMEBETWEENACTIONS, c.IsWaitConstant)
 		}
+
 	}
 	return nil
 }
@@ -191,7 +249,8 @@ func (v *Visit) sendClickEvent(rank int, quickview bool, customData map[string]i
 	event.DocumentTitle = v.LastResponse.Results[rank].Title
 	event.QueryPipeline = v.LastResponse.Pipeline
 	event.DocumentURL = v.LastResponse.Results[rank].ClickURI
-	event.DocumentPosition = rank + 1 //Document Position is 1 based in UA
+	event.DocumentPosition = v.LastQuery.FirstResult + rank + 1 //Document Position is absolute and 1 based in UA
+	v.LastClickedResult = &v.LastResponse.Results[rank]
 
 	if quickview {
 		event.ActionCause = "documentQuickview"
@@ -301,13 +360,33 @@ func (v *Visit) DecorateCustomMetadata(evt *ua.ActionEvent, customData map[strin
 	// Send all the possible random custom data that can be added from the config
 	// scenario file.
 	for _, elem := range v.Config.RandomCustomData {
-		evt.CustomData[elem.APIName] = randomStringArray(elem.Values)
+		v.setCustomDataValue(evt, elem.APIName, elem.Values[rand.Intn(len(elem.Values))])
+	}
+
+	// Reflect the context of the queries like the JS UI does.
+	for k, value := range v.Context {
+		evt.CustomData[CONTEXTCUSTOMDATAPREFIX+k] = value
+	}
+
+	// Override with the custom data of the scenario, then with the specific customData sent.
+	// Keys are sorted so generated values are the same for a given seed.
+	for _, k := range sortedKeys(v.CustomData) {
+		v.setCustomDataValue(evt, k, v.CustomData[k])
 	}
+	for _, k := range sortedKeys(customData) {
+		v.setCustomDataValue(evt, k, customData[k])
+	}
+}
 
-	// Override possible values of customData with the specific customData sent
-	for k, v := range customData {
-		evt.CustomData[k] = v
+// setCustomDataValue Evaluate a customData value and set it on the event, a value that
+// cannot be generated is not sent.
+func (v *Visit) setCustomDataValue(evt *ua.ActionEvent, key string, value interface{}) {
+	evaluated, err := v.evaluateCustomDataValue(value)
+	if err != nil {
+		Warning.Printf("Cannot generate customData %s : %v", key, err)
+		return
 	}
+	evt.CustomData[key] = evaluated
 }
 
 // FindDocumentRankByMatchingField Looks through the last response to a query to find a document rank
@@ -389,47 +468,21 @@ func generateEntitlementBesttech(isAnonymous bool) string {
 	return "Basic"
 }
 
-// SetupNTO Function to instanciate with specific values for NTO demo queries
-func (v *Visit) SetupNTO() {
-	gbs := []*search.GroupByRequest{}
-	q := &search.Query{
-		Q:               "",
-		CQ:              "",
-		AQ:              "NOT @objecttype==(User,Case,CollaborationGroup) AND NOT @filetype==(Folder, YouTubePlaylist, YouTubePlaylistItem)",
-		NumberOfResults: 20,
-		FirstResult:     0,
-		Tab:             "All",
-		GroupByRequests: gbs,
-	}
-
-	if v.Config.PartialMatch {
-		q.PartialMatch = v.Config.PartialMatch
-		q.PartialMatchKeywords = v.Config.PartialMatchKeywords
-		q.PartialMatchThreshold = v.Config.PartialMatchThreshold
-	}
-
-	if v.Config.Pipeline != "" {
-		q.Pipeline = v.Config.Pipeline
-	}
-
-	v.LastQuery = q
-
-	v.OriginLevel1 = "Community"
-	v.OriginLevel2 = ORIGINALL
-}
-
-// SetupGeneral Function to instanciate with non-specific values
+// SetupGeneral Function to instanciate the query of the visit with the values of the
+// config, customer specific values are set in the config queryDefaults.
 func (v *Visit) SetupGeneral() {
 	gbs := []*search.GroupByRequest{}
 	q := &search.Query{
 		Q:               "",
 		CQ:              "",
 		AQ:              "",
-		NumberOfResults: 20,
+		NumberOfResults: DEFAULTNUMBEROFRESULTS,
 		FirstResult:     0,
-		Tab:             "All",
+		Tab:             DEFAULTTAB,
 		GroupByRequests: gbs,
+		SearchHub:       v.Config.SearchHub,
 	}
+	v.Config.QueryDefaults.apply(q)
 
 	if v.Config.PartialMatch {
 		q.PartialMatch = v.Config.PartialMatch
@@ -441,7 +494,14 @@ func (v *Visit) SetupGeneral() {
 		q.Pipeline = v.Config.Pipeline
 	}
 
+	if v.Config.Tab != "" {
+		q.Tab = v.Config.Tab
+		v.OriginLevel2 = v.Config.Tab
+	}
+
 	v.LastQuery = q
+	v.Context = nil
+	v.SetContext(v.Config.Context)
 
 	v.OriginLevel1 = v.Config.DefaultOriginLevel1
 	if v.Config.DefaultOriginLevel2 != "" {
@@ -452,6 +512,15 @@ func (v *Visit) SetupGeneral() {
 	}
 }
 
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func getFieldValueFromRaw(raw map[string]interface{}, key string) interface{} {
 	value := raw[key]
 	if value != nil {